@@ -2,6 +2,7 @@
 Package 'cfg' provides functions for reading and writing configuration files and their coresponding string values.
 
 	Ignores '#' as comments, ','s denote multiple values.
+	Lines ending with '\' continue on the next line, indented lines following a value without a trailing comma are folded in to the value.
 
 	# Example config file.
 	[section]
@@ -15,12 +16,17 @@ Package 'cfg' provides functions for reading and writing configuration files and
 	key = value1,
 	      value2,
 	      value3
+	url = https://example.com/a/very/long/\
+	      path?with=arguments
+	description = A long description
+	    folded over multiple lines.
 */
 package cfg
 
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -28,12 +34,255 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 type Store struct {
-	file     string
-	mutex    sync.RWMutex
-	cfgStore map[string]map[string][]string
+	file      string
+	mutex     sync.RWMutex
+	read_only bool
+	secure    bool
+	fold_case bool
+	on_save   []saveHook
+	cfgStore  map[string]cfgSection
+	folded    map[string]string
+	comments  map[string]map[string]string
+	limits    Limits
+	origin    map[string]string
+	new_file  string
+	inherit   string
+	loaded    map[string]*fileState
+}
+
+// Transform applied to a key when saved.
+type saveHook struct {
+	section string
+	key     string
+	fn      func(values []string) ([]string, error)
+}
+
+var (
+	ErrReadOnly    = errors.New("Configuration is read-only.")
+	ErrSymlink     = errors.New("Refusing to write configuration through a symbolic link.")
+	ErrTooLarge    = errors.New("Maximum configuration size exceeded.")
+	ErrLineTooLong = errors.New("Maximum line length exceeded.")
+	ErrTooManyKeys = errors.New("Maximum number of keys in section exceeded.")
+)
+
+// Limits bounds configuration accepted by Parse, Defaults and File, a limit of 0 is unlimited.
+type Limits struct {
+	MaxSize       int64 // Maximum number of bytes read.
+	MaxLineLength int   // Maximum length of a line, including lines joined by '\'.
+	MaxKeys       int   // Maximum number of keys in a section.
+}
+
+// LimitError reports the line on which a limit was exceeded.
+type LimitError struct {
+	Line int
+	Err  error // ErrLineTooLong or ErrTooManyKeys.
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s on line %d.", strings.TrimSuffix(e.Err.Error(), "."), e.Line)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// Sets limits applied when parsing configuration, for configuration supplied by untrusted sources.
+func (s *Store) SetLimits(limits Limits) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.limits = limits
+}
+
+// Reader returning ErrTooLarge once more than remaining bytes are available.
+type sizeLimiter struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *sizeLimiter) Read(p []byte) (n int, err error) {
+	if l.remaining <= 0 {
+		var probe [1]byte
+		if n, err = l.r.Read(probe[:]); n > 0 {
+			return 0, ErrTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[0:l.remaining]
+	}
+	n, err = l.r.Read(p)
+	l.remaining -= int64(n)
+	return
+}
+
+// Sets store to read-only, Set, Unset and Save will return ErrReadOnly.
+func (s *Store) ReadOnly(enabled bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.read_only = enabled
+}
+
+// Registers a function to transform the values of section and key when saved, ie.. replacing a plaintext password with a reference.
+// The transformed values replace the values held in the store, so the function should leave already transformed values as is.
+func (s *Store) OnSave(section, key string, fn func(values []string) ([]string, error)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.on_save = append(s.on_save, saveHook{section, key, fn})
+}
+
+// Enables case-insensitive lookup of sections and keys as a fallback when an exact match is not found.
+// Sections and keys always retain the case they were written with.
+func (s *Store) IgnoreCase(enabled bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.fold_case = enabled
+}
+
+// Keys of a section and their values, with lowered key names interned for case-insensitive lookup.
+type cfgSection struct {
+	keys   map[string][]string
+	folded map[string]string
+}
+
+// Returns an empty section.
+func newSection() cfgSection {
+	return cfgSection{make(map[string][]string), make(map[string]string)}
+}
+
+// Resolves section name, falling back to a case-insensitive match when enabled.
+func (s *Store) sectionName(section string) string {
+	if _, ok := s.cfgStore[section]; ok || !s.fold_case {
+		return section
+	}
+	if name, ok := foldLookup(s.folded, section); ok {
+		return name
+	}
+	return section
+}
+
+// Resolves key name within section, falling back to a case-insensitive match when enabled.
+func (s *Store) keyName(section, key string) string {
+	sec := s.cfgStore[section]
+	if _, ok := sec.keys[key]; ok || !s.fold_case {
+		return key
+	}
+	if name, ok := foldLookup(sec.folded, key); ok {
+		return name
+	}
+	return key
+}
+
+// Returns section, creating it if it does not exist.
+func (s *Store) addSection(name string) cfgSection {
+	if sec, ok := s.cfgStore[name]; ok {
+		return sec
+	}
+	if s.cfgStore == nil {
+		s.cfgStore = make(map[string]cfgSection)
+		s.folded = make(map[string]string)
+	}
+	sec := newSection()
+	s.cfgStore[name] = sec
+	foldAdd(s.folded, name)
+	return sec
+}
+
+// Sets values of key in section, creating the section if it does not exist.
+func (s *Store) setKey(section, key string, values []string) {
+	sec := s.addSection(section)
+	if _, ok := sec.keys[key]; !ok {
+		foldAdd(sec.folded, key)
+	}
+	sec.keys[key] = values
+}
+
+// Removes key from section.
+func (s *Store) unsetKey(section, key string) {
+	sec := s.cfgStore[section]
+	if _, ok := sec.keys[key]; !ok {
+		return
+	}
+	delete(sec.keys, key)
+
+	lower := strings.ToLower(key)
+	if sec.folded[lower] != key {
+		return
+	}
+	delete(sec.folded, lower)
+	for k := range sec.keys {
+		if strings.ToLower(k) == lower {
+			foldAdd(sec.folded, k)
+		}
+	}
+}
+
+// Adds name to lowered names, which map to the first name in sorted order they match.
+func foldAdd(names map[string]string, name string) {
+	lower := strings.ToLower(name)
+	if first, ok := names[lower]; !ok || name < first {
+		names[lower] = name
+	}
+}
+
+// Looks up name in lowered names, short ASCII names are lowered without allocating.
+func foldLookup(names map[string]string, name string) (output string, found bool) {
+	var buf [64]byte
+	if len(name) <= len(buf) {
+		for i := 0; i < len(name); i++ {
+			c := name[i]
+			if c >= utf8.RuneSelf {
+				output, found = names[strings.ToLower(name)]
+				return
+			}
+			if 'A' <= c && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			buf[i] = c
+		}
+		output, found = names[string(buf[:len(name)])]
+		return
+	}
+	output, found = names[strings.ToLower(name)]
+	return
+}
+
+// Sets section that keys missing from other sections are inherited from, ie.. [default], an empty section disables inheritance.
+func (s *Store) Inherit(section string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.inherit = section
+}
+
+// Retrieves values of key under section, falling back to the inherited section.
+func (s *Store) lookup(section, key string) (result []string, found bool) {
+	section = s.sectionName(section)
+	if result, found = s.cfgStore[section].keys[s.keyName(section, key)]; found || s.inherit == empty {
+		return
+	}
+	if inherit := s.sectionName(s.inherit); inherit != section {
+		result, found = s.cfgStore[inherit].keys[s.keyName(inherit, key)]
+	}
+	return
+}
+
+// Sets the file sections not read from a file are saved to, defaults to the file last read by File.
+func (s *Store) SetNewSectionFile(file string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.new_file = file
+}
+
+// Enables secure saving, for configurations containing credentials.
+// Save will write the file with 0600 permissions and refuse to write through symbolic links.
+func (s *Store) SecureSave(enabled bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.secure = enabled
 }
 
 const (
@@ -54,7 +303,7 @@ func (s *Store) SGet(section, key string) string {
 		return empty
 	}
 
-	if result, found := s.cfgStore[section][key]; !found {
+	if result, found := s.lookup(section, key); !found {
 		return empty
 	} else {
 		if len(result) == 0 {
@@ -73,7 +322,7 @@ func (s *Store) MGet(section, key string) []string {
 		return []string{}
 	}
 
-	if result, found := s.cfgStore[section][key]; !found {
+	if result, found := s.lookup(section, key); !found {
 		return []string{}
 	} else {
 		if len(result) == 0 {
@@ -88,7 +337,7 @@ func (s *Store) Sanitize(section string, keys []string) (err error) {
 	if s.cfgStore == nil {
 		return fmt.Errorf("[%s] section does not exist, or is not configured.", section)
 	}
-	if _, ok := s.cfgStore[section]; !ok {
+	if _, ok := s.cfgStore[s.sectionName(section)]; !ok {
 		return fmt.Errorf("[%s] section does not exist, or is not configured.", section)
 	}
 	var missing_keys []string
@@ -117,7 +366,7 @@ func (s *Store) Get(section, key string) string {
 		found  bool
 	)
 
-	if result, found = s.cfgStore[section][key]; !found {
+	if result, found = s.lookup(section, key); !found {
 		return empty
 	}
 
@@ -144,7 +393,7 @@ func (s *Store) GetBool(section, key string) (output bool) {
 		found  bool
 	)
 
-	if result, found = s.cfgStore[section][key]; !found {
+	if result, found = s.lookup(section, key); !found || len(result) == 0 {
 		return false
 	}
 
@@ -175,7 +424,7 @@ func (s *Store) GetInt(section, key string) (output int64) {
 		found  bool
 	)
 
-	if result, found = s.cfgStore[section][key]; !found {
+	if result, found = s.lookup(section, key); !found {
 		return 0
 	}
 
@@ -198,7 +447,7 @@ func (s *Store) GetUint(section, key string) (output uint64) {
 		found  bool
 	)
 
-	if result, found = s.cfgStore[section][key]; !found {
+	if result, found = s.lookup(section, key); !found {
 		return 0
 	}
 
@@ -221,7 +470,7 @@ func (s *Store) GetFloat(section, key string) (output float64) {
 		found  bool
 	)
 
-	if result, found = s.cfgStore[section][key]; !found {
+	if result, found = s.lookup(section, key); !found {
 		return 0.0
 	}
 
@@ -230,6 +479,45 @@ func (s *Store) GetFloat(section, key string) (output float64) {
 	return
 }
 
+// Get Int64 Values from config, returning an error naming the first value that is not an integer.
+func (s *Store) MGetInt(section, key string) (output []int64, err error) {
+	for i, v := range s.MGet(section, key) {
+		n, e := strconv.ParseInt(v, 10, 64)
+		if e != nil {
+			return nil, fmt.Errorf("[%s] %s: value %d (%s) is not a valid integer.", section, key, i+1, v)
+		}
+		output = append(output, n)
+	}
+	return
+}
+
+// Get Boolean Values from config, returning an error naming the first value that is not yes, no, true or false.
+func (s *Store) MGetBool(section, key string) (output []bool, err error) {
+	for i, v := range s.MGet(section, key) {
+		switch strings.ToLower(v) {
+		case "yes", "true":
+			output = append(output, true)
+		case "no", "false":
+			output = append(output, false)
+		default:
+			return nil, fmt.Errorf("[%s] %s: value %d (%s) is not a valid boolean.", section, key, i+1, v)
+		}
+	}
+	return
+}
+
+// Get Duration Values from config, ie.. 30s, 5m, returning an error naming the first value that is not a duration.
+func (s *Store) MGetDuration(section, key string) (output []time.Duration, err error) {
+	for i, v := range s.MGet(section, key) {
+		d, e := time.ParseDuration(v)
+		if e != nil {
+			return nil, fmt.Errorf("[%s] %s: value %d (%s) is not a valid duration.", section, key, i+1, v)
+		}
+		output = append(output, d)
+	}
+	return
+}
+
 // Returns array of all sections in config file.
 func (s *Store) Sections() (out []string) {
 	s.mutex.RLock()
@@ -251,10 +539,10 @@ func (s *Store) Keys(section string) (out []string) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
-	if v, ok := s.cfgStore[section]; !ok {
+	if v, ok := s.cfgStore[s.sectionName(section)]; !ok {
 		return []string{empty}
 	} else {
-		for key := range v {
+		for key := range v.keys {
 			out = append(out, key)
 		}
 	}
@@ -262,7 +550,7 @@ func (s *Store) Keys(section string) (out []string) {
 	return
 }
 
-// Returns true if section or section and key exists.
+// Returns true if section or section and key exists, keys of an inherited section exist in every section.
 func (s *Store) Exists(input ...string) (found bool) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -276,14 +564,16 @@ func (s *Store) Exists(input ...string) (found bool) {
 		return false
 	}
 
+	section := s.sectionName(input[0])
+
 	if inlen > 0 {
-		if _, found = s.cfgStore[input[0]]; !found {
+		if _, found = s.cfgStore[section]; !found {
 			return
 		}
 	}
 	if inlen > 1 {
 		if found == true {
-			_, found = s.cfgStore[input[0]][input[1]]
+			_, found = s.lookup(section, input[1])
 			return
 		}
 	}
@@ -291,52 +581,67 @@ func (s *Store) Exists(input ...string) (found bool) {
 }
 
 // Unsets a specified key, or specified section.
-// If section is empty, section is removed.
-func (s *Store) Unset(input ...string) {
+// If section is empty, section is removed, returns ErrReadOnly if the store is read-only.
+func (s *Store) Unset(input ...string) (err error) {
+	s.mutex.RLock()
+	read_only := s.read_only
+	s.mutex.RUnlock()
+
+	if read_only {
+		return ErrReadOnly
+	}
 
 	if s.cfgStore == nil {
-		return
+		return nil
 	}
 
 	switch len(input) {
 	case 0:
-		return
+		return nil
 	case 1:
 		keys := s.Keys(input[0])
 		s.mutex.Lock()
+		section := s.sectionName(input[0])
 		for _, key := range keys {
-			delete(s.cfgStore[input[0]], key)
+			s.unsetKey(section, key)
 		}
+		delete(s.comments, section)
 	default:
 		s.mutex.Lock()
-		delete(s.cfgStore[input[0]], input[1])
+		section := s.sectionName(input[0])
+		key := s.keyName(section, input[1])
+		s.unsetKey(section, key)
+		delete(s.comments[section], key)
 	}
 	s.mutex.Unlock()
+	return nil
 }
 
 // Sets key = values under [section], updates Store and saves to file.
 func (s *Store) Set(section, key string, value ...interface{}) (err error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
-	var newValue []string
 
-	if s.cfgStore == nil {
-		s.cfgStore = make(map[string]map[string][]string)
+	if s.read_only {
+		return ErrReadOnly
 	}
 
+	var newValue []string
+
 	for _, val := range value {
 		newValue = append(newValue, fmt.Sprintf("%v", val))
 	}
 
-	// Create new map if one doesn't exist.
-	if _, ok := s.cfgStore[section]; !ok {
-		s.cfgStore[section] = make(map[string][]string)
-	}
+	section = s.sectionName(section)
+	key = s.keyName(section, key)
+
+	// Section is created even when no values are given.
+	s.addSection(section)
 
 	if len(value) == 0 {
-		delete(s.cfgStore[section], key)
+		s.unsetKey(section, key)
 	} else {
-		s.cfgStore[section][key] = newValue
+		s.setKey(section, key, newValue)
 	}
 	return
 }
@@ -423,25 +728,171 @@ func cleanSplit(input string, sepr rune, instances int) (out []string) {
 	return
 }
 
+// Logical line of configuration, after comments are removed and continuations are joined.
+type cfgLine struct {
+	num     int
+	indent  int
+	text    string
+	comment []string
+}
+
+// Reads configuration lines, lines ending with a backslash are joined with the following line.
+// Comment lines directly preceding a line are attached to it.
+func readLines(input io.Reader, limits Limits) (lines []cfgLine, err error) {
+	if limits.MaxSize > 0 {
+		input = &sizeLimiter{input, limits.MaxSize}
+	}
+
+	sc := bufio.NewScanner(input)
+	if limits.MaxLineLength > 0 && limits.MaxLineLength < bufio.MaxScanTokenSize {
+		sc.Buffer(make([]byte, 0, limits.MaxLineLength+1), limits.MaxLineLength+1)
+	}
+
+	var (
+		num     int
+		joining bool
+		pending cfgLine
+		comment []string
+	)
+
+	for sc.Scan() {
+		num++
+		raw := sc.Text()
+		trimmed := strings.TrimSpace(raw)
+
+		if !joining {
+			if len(trimmed) == 0 {
+				comment = nil
+			} else if trimmed[0] == '#' {
+				comment = append(comment, trimmed)
+				continue
+			}
+		}
+
+		txt := strings.TrimSpace(cleanSplit(raw, '#', 1)[0])
+
+		if joining {
+			pending.text = pending.text + txt
+			joining = false
+		} else {
+			pending = cfgLine{num, len(raw) - len(strings.TrimLeft(raw, " \t")), txt, comment}
+			comment = nil
+		}
+
+		if limits.MaxLineLength > 0 && len(pending.text) > limits.MaxLineLength {
+			return nil, &LimitError{num, ErrLineTooLong}
+		}
+
+		if strings.HasSuffix(pending.text, "\\") && !strings.HasSuffix(pending.text, "\\\\") {
+			pending.text = strings.TrimSuffix(pending.text, "\\")
+			joining = true
+			continue
+		}
+
+		lines = append(lines, pending)
+	}
+
+	if err = sc.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			err = &LimitError{num + 1, ErrLineTooLong}
+		}
+		return nil, err
+	}
+
+	if joining {
+		lines = append(lines, pending)
+	}
+
+	return lines, nil
+}
+
+// Returns text of comment lines, with '#' and a following space removed.
+func commentText(lines []string) string {
+	var text []string
+	for _, l := range lines {
+		l = strings.TrimPrefix(strings.TrimSpace(l), "#")
+		text = append(text, strings.TrimPrefix(l, " "))
+	}
+	return strings.Join(text, "\n")
+}
+
+// Formats comment text in to comment lines.
+func commentLines(text string) (lines []string) {
+	if text == "" {
+		return nil
+	}
+	for _, l := range strings.Split(text, "\n") {
+		if l == "" {
+			lines = append(lines, "#")
+		} else {
+			lines = append(lines, "# "+l)
+		}
+	}
+	return
+}
+
+// Returns the comment block preceding key in section, with '#' removed from each line.
+func (s *Store) Comment(section, key string) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	section = s.sectionName(section)
+	return s.comments[section][s.keyName(section, key)]
+}
+
+// Sets the comment block written before key in section on Save, an empty comment removes it.
+func (s *Store) SetComment(section, key, comment string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.read_only {
+		return ErrReadOnly
+	}
+
+	section = s.sectionName(section)
+	key = s.keyName(section, key)
+
+	s.setComment(section, key, comment)
+	return nil
+}
+
+// Records comment of key, mutex must be held by caller.
+func (s *Store) setComment(section, key, comment string) {
+	if s.comments == nil {
+		s.comments = make(map[string]map[string]string)
+	}
+	if s.comments[section] == nil {
+		s.comments[section] = make(map[string]string)
+	}
+	s.comments[section][key] = comment
+}
+
 // Parses the configuration data.
-func (s *Store) config_parser(input io.Reader, overwrite bool) (err error) {
+// Indented lines without '=' following a value without a trailing comma are folded in to that value, separated by a space.
+// Sections are recorded as originating from origin, if given.
+func (s *Store) config_parser(input io.Reader, overwrite bool, origin string) (err error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	sc := bufio.NewScanner(input)
+	lines, err := readLines(input, s.limits)
+	if err != nil {
+		return err
+	}
 
 	if s.cfgStore == nil {
-		s.cfgStore = make(map[string]map[string][]string)
+		s.cfgStore = make(map[string]cfgSection)
+		s.folded = make(map[string]string)
 	}
 
 	var section, key string
-	var line int
 	var added_sections []string
 	var added_keys []string
+	var key_indent int
+	var foldable bool
 
-	for sc.Scan() {
-		line++
-		txt := strings.TrimSpace(cleanSplit(sc.Text(), '#', 1)[0])
+	for _, l := range lines {
+		line := l.num
+		txt := l.text
 
 		write_ok := func(key string) bool {
 			if overwrite {
@@ -456,43 +907,68 @@ func (s *Store) config_parser(input io.Reader, overwrite bool) (err error) {
 		}
 
 		if len(txt) == 0 {
+			foldable = false
 			continue
 		}
 		if txt[0] == '[' && txt[len(txt)-1] == ']' {
+			foldable = false
 			added_keys = make([]string, 0)
-			section = strings.TrimSuffix(strings.TrimPrefix(txt, "["), "]")
+			section = s.sectionName(strings.TrimSuffix(strings.TrimPrefix(txt, "["), "]"))
 			for _, s := range added_sections {
 				if s == section {
 					return fmt.Errorf("Duplicate section [%s] encountered on line %d.", section, line)
 				}
 			}
 			added_sections = append(added_sections, section)
-			if s.cfgStore[section] == nil {
-				s.cfgStore[section] = make(map[string][]string)
+			if origin != empty {
+				if s.origin == nil {
+					s.origin = make(map[string]string)
+				}
+				s.origin[section] = origin
 			}
+			s.addSection(section)
 		} else {
 			if section == empty {
 				return cfgErr(line)
 			}
 			split := cleanSplit(txt, '=', 1)
+			// Fold indented line in to the previous value, lines with '=' are always treated as keys.
+			if foldable && l.indent > key_indent && len(split) < 2 {
+				if write_ok(key) {
+					values := s.cfgStore[section].keys[key]
+					if n := len(values); n > 0 {
+						values[n-1] = values[n-1] + " " + txt
+					} else {
+						s.setKey(section, key, append(values, txt))
+					}
+				}
+				continue
+			}
 			if len(split) == 2 {
-				key = strings.TrimSpace(split[0])
+				key = s.keyName(section, strings.TrimSpace(split[0]))
 				txt = strings.TrimSpace(split[1])
-				if _, ok := s.cfgStore[section][key]; !ok {
+				key_indent = l.indent
+				if _, ok := s.cfgStore[section].keys[key]; !ok {
+					if s.limits.MaxKeys > 0 && len(s.cfgStore[section].keys) >= s.limits.MaxKeys {
+						return &LimitError{line, ErrTooManyKeys}
+					}
 					added_keys = append(added_keys, key)
 				}
 				if write_ok(key) {
-					delete(s.cfgStore[section], key)
+					s.unsetKey(section, key)
+					if len(l.comment) > 0 {
+						s.setComment(section, key, commentText(l.comment))
+					}
 				}
 			}
 			if write_ok(key) {
 				for _, v := range cleanSplit(txt, ',', -1) {
 					if len(v) > 0 {
-						s.cfgStore[section][key] = append(s.cfgStore[section][key], strings.TrimSpace(v))
+						s.setKey(section, key, append(s.cfgStore[section].keys[key], strings.TrimSpace(v)))
 					}
 				}
 			}
-
+			foldable = len(txt) > 0 && !strings.HasSuffix(txt, ",")
 		}
 	}
 	return nil
@@ -500,15 +976,17 @@ func (s *Store) config_parser(input io.Reader, overwrite bool) (err error) {
 
 // Sets default settings for configuration store, ignores if already set.
 func (s *Store) Defaults(input string) (err error) {
-	return s.config_parser(strings.NewReader(input), false)
+	return s.config_parser(strings.NewReader(input), false, empty)
 }
 
 // Will parse a string, but overwrite existing config.
 func (s *Store) Parse(input string) (err error) {
-	return s.config_parser(strings.NewReader(input), true)
+	return s.config_parser(strings.NewReader(input), true, empty)
 }
 
 // Reads configuration file and returns Store, file must exist even if empty.
+// File may be called again to layer further files, Save writes each section back to the file it was last read from.
+// Changes made to the file by another process before Save are merged, see ErrConflict.
 func (s *Store) File(file string) (err error) {
 	s.file = file
 	f, err := os.Open(file)
@@ -516,10 +994,18 @@ func (s *Store) File(file string) (err error) {
 		return err
 	}
 	defer f.Close()
-	err = s.config_parser(f, true)
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	var data bytes.Buffer
+	err = s.config_parser(io.TeeReader(f, &data), true, file)
 	if err != nil {
-		return fmt.Errorf("%s: %s", file, err)
+		return fmt.Errorf("%s: %w", file, err)
 	}
+	s.mutex.Lock()
+	s.track(file, fi, data.Bytes())
+	s.mutex.Unlock()
 	return
 }
 
@@ -529,13 +1015,15 @@ func (s *Store) TrimSave(sections ...string) error {
 }
 
 // Saves [section](s) to file, recording all key = value pairs, if empty, save all sections.
+// Keys changed in the file by another process since it was read are merged in first,
+// a *ConflictError wrapping ErrConflict is returned if the Store changed the same keys.
 func (s *Store) Save(sections ...string) error {
 	return s.save(false, sections...)
 }
 
 func (s *Store) save(clear_unused_keys bool, sections ...string) error {
 
-	if s.file == empty {
+	if s.file == empty && s.new_file == empty {
 		return fmt.Errorf("No file specified for write operation.")
 	}
 
@@ -546,10 +1034,81 @@ func (s *Store) save(clear_unused_keys bool, sections ...string) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	f, err := os.Open(s.file)
+	if s.read_only {
+		return ErrReadOnly
+	}
+
+	// Apply transforms to keys being saved, matching their names as lookups do when case is ignored.
+	for _, h := range s.on_save {
+		h_section := s.sectionName(h.section)
+		for _, section := range sections {
+			if s.sectionName(section) != h_section {
+				continue
+			}
+			key := s.keyName(h_section, h.key)
+			values, found := s.cfgStore[h_section].keys[key]
+			if !found {
+				continue
+			}
+			values, err := h.fn(values)
+			if err != nil {
+				return fmt.Errorf("[%s] %s: %s", h_section, key, err)
+			}
+			s.cfgStore[h_section].keys[key] = values
+		}
+	}
+
+	// Group sections by the file they are saved to.
+	var files []string
+	file_sections := make(map[string][]string)
+	for _, section := range sections {
+		file := s.sectionFile(section)
+		if _, ok := file_sections[file]; !ok {
+			files = append(files, file)
+		}
+		file_sections[file] = append(file_sections[file], section)
+	}
+
+	for _, file := range files {
+		if err := s.saveFile(file, clear_unused_keys, file_sections[file]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns file section is saved to, the file it was loaded from or the file set by SetNewSectionFile.
+func (s *Store) sectionFile(section string) string {
+	if file, ok := s.origin[section]; ok {
+		return file
+	}
+	if s.new_file != empty {
+		return s.new_file
+	}
+	return s.file
+}
+
+// Writes sections to file, mutex must be held by caller.
+func (s *Store) saveFile(file string, clear_unused_keys bool, sections []string) error {
+	if err := s.mergeFile(file); err != nil {
+		return err
+	}
+
+	// Secure saves refuse a symbolic link as the file is opened, rather than checking beforehand.
+	open := os.OpenFile
+	if s.secure {
+		open = openNoFollow
+	}
+
+	f, err := open(file, os.O_RDONLY, 0)
 	if err != nil {
 		if os.IsNotExist(err) {
-			f, err = os.Create(s.file)
+			if s.secure {
+				f, err = os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+			} else {
+				f, err = os.Create(file)
+			}
 			if err != nil {
 				return err
 			}
@@ -630,39 +1189,7 @@ func (s *Store) save(clear_unused_keys bool, sections ...string) error {
 		if len(v) == 0 && clear_unused_keys {
 			return nil
 		}
-		_, err = dst.WriteString(k + " = ")
-		if err != nil {
-			return err
-		}
-		spacer := make([]byte, len(k+" = "))
-		for n := range spacer {
-			spacer[n] = ' '
-		}
-		vlen := len(v)
-		var str string
-		if vlen == 0 {
-			_, err = dst.WriteString(str + "\n")
-			return
-		}
-		for n, txt := range v {
-			if strings.Contains(txt, ",") {
-				txt = strconv.Quote(txt)
-			}
-			if n > 0 {
-				str = fmt.Sprintf("%s%s", spacer, txt)
-			} else {
-				str = txt
-			}
-			if n == vlen-1 {
-				_, err = dst.WriteString(str + "\n")
-			} else {
-				_, err = dst.WriteString(str + ",\n")
-			}
-			if err != nil {
-				return err
-			}
-		}
-		return
+		return writeKV(dst, k, v)
 	}
 
 	tmp_dst := new(bytes.Buffer)
@@ -713,11 +1240,27 @@ func (s *Store) save(clear_unused_keys bool, sections ...string) error {
 				return err
 			}
 
+			// Comment lines are held until the following line, so comments set with SetComment can replace them.
+			var pending []string
+
+			writeLines := func(lines []string) (err error) {
+				for _, l := range lines {
+					if _, err = tmp_dst.WriteString(l + "\n"); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
 			sc := bufio.NewScanner(&sec_buf)
 			for sc.Scan() {
 				raw := sc.Text()
 				txt := strings.TrimSpace(raw)
 				if len(txt) == 0 {
+					if err = writeLines(pending); err != nil {
+						return err
+					}
+					pending = pending[0:0]
 					_, err = tmp_dst.WriteString("\n")
 					if err != nil {
 						return err
@@ -726,10 +1269,7 @@ func (s *Store) save(clear_unused_keys bool, sections ...string) error {
 				}
 				switch txt[0] {
 				case '#':
-					_, err = tmp_dst.WriteString(raw + "\n")
-					if err != nil {
-						return err
-					}
+					pending = append(pending, raw)
 				case '[':
 					if txt[len(txt)-1] == ']' {
 						if txt == "["+section+"]" {
@@ -739,17 +1279,27 @@ func (s *Store) save(clear_unused_keys bool, sections ...string) error {
 				default:
 					if strings.ContainsRune(txt, '=') {
 						key := strings.TrimSpace(strings.Split(txt, "=")[0])
-						if err = storeKV(tmp_dst, key, s.cfgStore[section]); err != nil {
+						if comment, ok := s.comments[section][key]; ok && comment != commentText(pending) {
+							pending = commentLines(comment)
+						}
+						if err = writeLines(pending); err != nil {
+							return err
+						}
+						pending = pending[0:0]
+						if err = storeKV(tmp_dst, key, s.cfgStore[section].keys); err != nil {
 							return err
 						}
 						used_keys = append(used_keys, key)
 					}
 				}
 			}
+			if err = writeLines(pending); err != nil {
+				return err
+			}
 
 			var all_keys []string
 
-			for key := range s.cfgStore[section] {
+			for key := range s.cfgStore[section].keys {
 				all_keys = append(all_keys, key)
 			}
 			sort.Strings(all_keys)
@@ -761,7 +1311,12 @@ func (s *Store) save(clear_unused_keys bool, sections ...string) error {
 						continue outter_loop
 					}
 				}
-				if err = storeKV(tmp_dst, k, s.cfgStore[section]); err != nil {
+				if len(s.cfgStore[section].keys[k]) > 0 || !clear_unused_keys {
+					if err = writeLines(commentLines(s.comments[section][k])); err != nil {
+						return err
+					}
+				}
+				if err = storeKV(tmp_dst, k, s.cfgStore[section].keys); err != nil {
 					return err
 				}
 			}
@@ -772,12 +1327,20 @@ func (s *Store) save(clear_unused_keys bool, sections ...string) error {
 		}
 	}
 
-	destfile, err := os.OpenFile(s.file, os.O_RDWR|os.O_TRUNC, 0600)
+	destfile, err := open(file, os.O_RDWR|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
 	defer destfile.Close()
 
+	if s.secure {
+		if err = destfile.Chmod(0600); err != nil {
+			return err
+		}
+	}
+
+	data := tmp_dst.Bytes()
+
 	_, err = io.Copy(destfile, tmp_dst)
 	if err != nil {
 		return err
@@ -788,5 +1351,48 @@ func (s *Store) save(clear_unused_keys bool, sections ...string) error {
 		return err
 	}
 
+	fi, err := destfile.Stat()
+	if err != nil {
+		return err
+	}
+	s.track(file, fi, data)
+
 	return nil
 }
+
+// Writes key and its values, continuing multiple values on following lines aligned with the first.
+func writeKV(dst *bytes.Buffer, k string, v []string) (err error) {
+	_, err = dst.WriteString(k + " = ")
+	if err != nil {
+		return err
+	}
+	spacer := make([]byte, len(k+" = "))
+	for n := range spacer {
+		spacer[n] = ' '
+	}
+	vlen := len(v)
+	var str string
+	if vlen == 0 {
+		_, err = dst.WriteString(str + "\n")
+		return
+	}
+	for n, txt := range v {
+		if strings.Contains(txt, ",") {
+			txt = strconv.Quote(txt)
+		}
+		if n > 0 {
+			str = fmt.Sprintf("%s%s", spacer, txt)
+		} else {
+			str = txt
+		}
+		if n == vlen-1 {
+			_, err = dst.WriteString(str + "\n")
+		} else {
+			_, err = dst.WriteString(str + ",\n")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return
+}
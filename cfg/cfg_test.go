@@ -0,0 +1,209 @@
+package cfg
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const test_config = `
+[Server]
+Host = example.com
+Ports = 80, 443
+
+[default]
+Timeout = 30
+`
+
+// Returns store parsed from test_config.
+func testStore(t testing.TB) *Store {
+	t.Helper()
+	var s Store
+	if err := s.Parse(test_config); err != nil {
+		t.Fatal(err)
+	}
+	return &s
+}
+
+func TestIgnoreCase(t *testing.T) {
+	s := testStore(t)
+
+	tests := []struct {
+		fold    bool
+		section string
+		key     string
+		want    string
+	}{
+		{false, "Server", "Host", "example.com"},
+		{false, "server", "host", ""},
+		{true, "server", "host", "example.com"},
+		{true, "SERVER", "HOST", "example.com"},
+		{true, "Server", "missing", ""},
+	}
+
+	for _, tt := range tests {
+		s.IgnoreCase(tt.fold)
+		if got := s.Get(tt.section, tt.key); got != tt.want {
+			t.Errorf("IgnoreCase(%v): Get(%q, %q) = %q, want %q", tt.fold, tt.section, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreCaseFirstMatch(t *testing.T) {
+	s := testStore(t)
+
+	// Keys differing only in case resolve to the first in sorted order, until it is removed.
+	for _, key := range []string{"name", "NAME", "Name"} {
+		if err := s.Set("Server", key, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.IgnoreCase(true)
+	if got := s.Get("Server", "nAmE"); got != "NAME" {
+		t.Errorf("Get of folded key = %q, want %q", got, "NAME")
+	}
+	s.Unset("Server", "NAME")
+	if got := s.Get("Server", "nAmE"); got != "Name" {
+		t.Errorf("Get of folded key after Unset = %q, want %q", got, "Name")
+	}
+
+	// Sections added by Set are found by their folded name.
+	if err := s.Set("Client", "Retries", 3); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.GetInt("client", "retries"); got != 3 {
+		t.Errorf("GetInt of folded section = %d, want 3", got)
+	}
+}
+
+func TestInherit(t *testing.T) {
+	s := testStore(t)
+	s.Inherit("default")
+
+	if got := s.GetInt("Server", "Timeout"); got != 30 {
+		t.Errorf("GetInt of inherited key = %d, want 30", got)
+	}
+	if got := s.MGet("Server", "Ports"); len(got) != 2 || got[1] != "443" {
+		t.Errorf("MGet = %q", got)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	s := testStore(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.Get("Server", "Host")
+	}
+}
+
+func BenchmarkGetIgnoreCase(b *testing.B) {
+	s := testStore(b)
+	s.IgnoreCase(true)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.Get("SERVER", "HOST")
+	}
+}
+
+func BenchmarkGetInherited(b *testing.B) {
+	s := testStore(b)
+	s.Inherit("default")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.Get("Server", "Timeout")
+	}
+}
+
+func BenchmarkGetLarge(b *testing.B) {
+	var s Store
+	for i := 0; i < 1000; i++ {
+		if err := s.Set(fmt.Sprintf("Section%d", i%10), fmt.Sprintf("Key%d", i), i); err != nil {
+			b.Fatal(err)
+		}
+	}
+	s.IgnoreCase(true)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Get("section5", "key505")
+	}
+}
+
+func BenchmarkGetParallel(b *testing.B) {
+	s := testStore(b)
+	s.IgnoreCase(true)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.Get("server", "host")
+		}
+	})
+}
+
+func TestReadOnly(t *testing.T) {
+	s := testStore(t)
+	s.ReadOnly(true)
+
+	if err := s.Set("Server", "Host", "other"); err != ErrReadOnly {
+		t.Errorf("Set returned %v, want ErrReadOnly", err)
+	}
+	if err := s.Unset("Server", "Host"); err != ErrReadOnly {
+		t.Errorf("Unset returned %v, want ErrReadOnly", err)
+	}
+	if got := s.Get("Server", "Host"); got != "example.com" {
+		t.Errorf("Get after refused Unset = %q", got)
+	}
+}
+
+func TestSecureSaveSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.ini")
+	link := filepath.Join(dir, "link.ini")
+
+	if err := os.WriteFile(target, []byte("[Server]\nHost = example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip(err)
+	}
+
+	var s Store
+	if err := s.File(link); err != nil {
+		t.Fatal(err)
+	}
+	s.SecureSave(true)
+	if err := s.Set("Server", "Host", "other"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != ErrSymlink {
+		t.Errorf("Save through symbolic link returned %v, want ErrSymlink", err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "[Server]\nHost = example.com\n" {
+		t.Errorf("target of link was written, now %q", data)
+	}
+}
+
+func TestOnSaveIgnoreCase(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.ini")
+	if err := os.WriteFile(file, []byte("[auth]\npassword = secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var s Store
+	if err := s.File(file); err != nil {
+		t.Fatal(err)
+	}
+	s.IgnoreCase(true)
+	s.OnSave("Auth", "Password", func(values []string) ([]string, error) {
+		return []string{"ref:vault"}, nil
+	})
+
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(file); strings.Contains(string(data), "secret") {
+		t.Errorf("saved file holds plaintext value: %q", data)
+	}
+}
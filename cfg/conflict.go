@@ -0,0 +1,147 @@
+package cfg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+var ErrConflict = errors.New("Configuration file was changed by another process.")
+
+// ConflictError is returned by Save when keys changed on disk since the file was loaded were also changed in the Store.
+type ConflictError struct {
+	File    string
+	Changes []Change // Changes made on disk to keys also changed in the Store.
+}
+
+func (e *ConflictError) Error() string {
+	lines := []string{fmt.Sprintf("%s: %s", e.File, ErrConflict.Error())}
+	for _, c := range e.Changes {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
+
+// State of a configuration file as last read or written.
+type fileState struct {
+	mod_time time.Time
+	size     int64
+	sum      [sha256.Size]byte
+	data     []byte
+}
+
+// Records state of file, mutex must be held by caller.
+func (s *Store) track(file string, fi os.FileInfo, data []byte) {
+	if s.loaded == nil {
+		s.loaded = make(map[string]*fileState)
+	}
+	s.loaded[file] = &fileState{
+		mod_time: fi.ModTime(),
+		size:     fi.Size(),
+		sum:      sha256.Sum256(data),
+		data:     append([]byte(nil), data...),
+	}
+}
+
+// Parses data in to a new Store with the same case folding and limits.
+func (s *Store) parseCopy(data []byte) (*Store, error) {
+	c := &Store{fold_case: s.fold_case, limits: s.limits}
+	if err := c.config_parser(bytes.NewReader(data), true, empty); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Merges changes made to file since it was last read or written, mutex must be held by caller.
+// Keys changed on disk are taken in to the Store, unless the Store changed them as well, which returns a *ConflictError.
+func (s *Store) mergeFile(file string) error {
+	state, ok := s.loaded[file]
+	if !ok {
+		return nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.ModTime().Equal(state.mod_time) && fi.Size() == state.size {
+		return nil
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if sha256.Sum256(data) == state.sum {
+		return nil
+	}
+
+	base, err := s.parseCopy(state.data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	disk, err := s.parseCopy(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	theirs := Diff(base, disk)
+
+	// Sections loaded from another file are left as they are.
+	owned := func(section string) bool {
+		origin, ok := s.origin[section]
+		return !ok || origin == file
+	}
+
+	var conflicts []Change
+	for _, c := range theirs {
+		if !owned(c.Section) {
+			continue
+		}
+		ours, found := s.cfgStore[c.Section].keys[c.Key]
+		if (found != (c.Type != Added) || !equalValues(ours, c.Old)) && (found != (c.Type != Removed) || !equalValues(ours, c.New)) {
+			conflicts = append(conflicts, c)
+		}
+	}
+	if len(conflicts) > 0 {
+		return &ConflictError{file, conflicts}
+	}
+
+	for _, c := range theirs {
+		if !owned(c.Section) {
+			continue
+		}
+		if c.Type == Removed {
+			s.unsetKey(c.Section, c.Key)
+			continue
+		}
+		if _, ok := s.cfgStore[c.Section]; !ok {
+			if s.origin == nil {
+				s.origin = make(map[string]string)
+			}
+			s.origin[c.Section] = file
+		}
+		s.setKey(c.Section, c.Key, append([]string(nil), c.New...))
+	}
+	s.track(file, fi, data)
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package cfg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	Added    = iota // Key exists only in the second store.
+	Removed         // Key exists only in the first store.
+	Modified        // Key exists in both stores with different values.
+)
+
+// Change describes a difference of a key between two stores.
+type Change struct {
+	Type    int
+	Section string
+	Key     string
+	Old     []string
+	New     []string
+}
+
+func (c Change) String() string {
+	switch c.Type {
+	case Added:
+		return fmt.Sprintf("+ [%s] %s = %s", c.Section, c.Key, strings.Join(c.New, ", "))
+	case Removed:
+		return fmt.Sprintf("- [%s] %s = %s", c.Section, c.Key, strings.Join(c.Old, ", "))
+	default:
+		return fmt.Sprintf("~ [%s] %s = %s -> %s", c.Section, c.Key, strings.Join(c.Old, ", "), strings.Join(c.New, ", "))
+	}
+}
+
+// Returns a copy of the store's contents.
+func (s *Store) snapshot() map[string]map[string][]string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	out := make(map[string]map[string][]string)
+	for section, sec := range s.cfgStore {
+		out[section] = make(map[string][]string)
+		for k, v := range sec.keys {
+			out[section][k] = append([]string(nil), v...)
+		}
+	}
+	return out
+}
+
+// Compares two values.
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns keys added, removed or modified going from store a to store b, ordered by section and key.
+func Diff(a, b *Store) (changes []Change) {
+	old := a.snapshot()
+	new := b.snapshot()
+
+	var sections []string
+	for section := range old {
+		sections = append(sections, section)
+	}
+	for section := range new {
+		if _, ok := old[section]; !ok {
+			sections = append(sections, section)
+		}
+	}
+	sort.Strings(sections)
+
+	for _, section := range sections {
+		var keys []string
+		for k := range old[section] {
+			keys = append(keys, k)
+		}
+		for k := range new[section] {
+			if _, ok := old[section][k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			o, in_old := old[section][k]
+			n, in_new := new[section][k]
+			switch {
+			case !in_old:
+				changes = append(changes, Change{Added, section, k, nil, n})
+			case !in_new:
+				changes = append(changes, Change{Removed, section, k, o, nil})
+			case !equalValues(o, n):
+				changes = append(changes, Change{Modified, section, k, o, n})
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,157 @@
+package cfg
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Edit opens [section] in the editor named by $VISUAL or $EDITOR, applying the result once the editor exits.
+// The edited text must parse and hold only [section], otherwise an error is returned and the Store is left unchanged.
+// Keys and comments of the section are replaced as a whole, then saved if the Store has a file, reverting them should Save fail.
+// Should the section be changed while the editor is open, the edit is discarded and ErrConflict is returned.
+// The editor is given the terminal as is, callers drawing to it (ie.. nfo's progress bars) should pause their output around Edit.
+func (s *Store) Edit(section string) (err error) {
+	s.mutex.RLock()
+	if s.read_only {
+		s.mutex.RUnlock()
+		return ErrReadOnly
+	}
+
+	section = s.sectionName(section)
+	original, err := s.renderSection(section)
+	s.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp("", "cfg-*.ini")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err = f.Write(original); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	if err = runEditor(f.Name()); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, original) {
+		return nil
+	}
+
+	edited := &Store{fold_case: s.fold_case, limits: s.limits}
+	if err = edited.config_parser(bytes.NewReader(data), true, empty); err != nil {
+		return fmt.Errorf("[%s]: %w", section, err)
+	}
+	for name := range edited.cfgStore {
+		if name != section {
+			return fmt.Errorf("[%s]: edit may not add section [%s].", section, name)
+		}
+	}
+
+	values, ok := edited.cfgStore[section]
+	if !ok {
+		values = newSection()
+	}
+
+	s.mutex.Lock()
+	current, err := s.renderSection(section)
+	if err != nil {
+		s.mutex.Unlock()
+		return err
+	}
+	if !bytes.Equal(current, original) {
+		s.mutex.Unlock()
+		return fmt.Errorf("[%s]: %w", section, ErrConflict)
+	}
+	old_values, old_comments := s.addSection(section), s.comments[section]
+	s.cfgStore[section] = values
+	if s.comments == nil {
+		s.comments = make(map[string]map[string]string)
+	}
+	s.comments[section] = edited.comments[section]
+	applied, _ := s.renderSection(section)
+	has_file := s.file != empty || s.new_file != empty
+	s.mutex.Unlock()
+
+	if !has_file {
+		return nil
+	}
+
+	if err = s.Save(section); err != nil {
+		// Only revert when the section still holds the edit, rather than undo later changes.
+		s.mutex.Lock()
+		if current, _ := s.renderSection(section); bytes.Equal(current, applied) {
+			s.cfgStore[section] = old_values
+			s.comments[section] = old_comments
+		}
+		s.mutex.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Renders keys and comments of section as presented to the editor, mutex must be held by caller.
+func (s *Store) renderSection(section string) ([]byte, error) {
+	var keys []string
+	for k := range s.cfgStore[section].keys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b bytes.Buffer
+	b.WriteString("[" + section + "]\n")
+	for _, k := range keys {
+		for _, l := range commentLines(s.comments[section][k]) {
+			b.WriteString(l + "\n")
+		}
+		if err := writeKV(&b, k, s.cfgStore[section].keys[k]); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// Runs editor on file, attached to the terminal.
+func runEditor(file string) error {
+	var args []string
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if args = strings.Fields(os.Getenv(env)); len(args) > 0 {
+			break
+		}
+	}
+	if len(args) == 0 {
+		if runtime.GOOS == "windows" {
+			args = []string{"notepad"}
+		} else {
+			args = []string{"vi"}
+		}
+	}
+
+	cmd := exec.Command(args[0], append(args[1:], file)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	return nil
+}
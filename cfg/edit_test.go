@@ -0,0 +1,52 @@
+package cfg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestEditConflict(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("editor is a shell script")
+	}
+	dir := t.TempDir()
+	started := filepath.Join(dir, "started")
+	release := filepath.Join(dir, "release")
+
+	editor := filepath.Join(dir, "editor.sh")
+	script := "#!/bin/sh\necho 'Host = edited' >> \"$1\"\ntouch " + started + "\nwhile [ ! -f " + release + " ]; do sleep 0.01; done\n"
+	if err := os.WriteFile(editor, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", editor)
+
+	s := testStore(t)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Edit("Server") }()
+
+	for {
+		if _, err := os.Stat(started); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := s.Set("Server", "Host", "concurrent"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(release, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-done; !errors.Is(err, ErrConflict) {
+		t.Fatalf("Edit = %v, want ErrConflict", err)
+	}
+	if v := s.Get("Server", "Host"); v != "concurrent" {
+		t.Errorf("Host = %q, want %q", v, "concurrent")
+	}
+}
@@ -0,0 +1,92 @@
+package cfg
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	GlobalSection = "global" // Section applied to the global flag set by ApplyFlags.
+	FlagSource    = "config" // Source reported for flags applied by ApplyFlags, matching eflag.SourceConfig.
+)
+
+// FlagSet is a set of flags ApplyFlags can apply configuration to, such as an *eflag.EFlagSet.
+type FlagSet interface {
+	Name() string
+	Lookup(name string) *flag.Flag
+	ResolveAlias(name string) string
+	SetSource(name, source string)
+}
+
+// MultiValue is a flag value holding multiple values, such as those of eflag's Multi flags.
+type MultiValue interface {
+	SetValues(values []string)
+}
+
+// Applies keys of the [global] section as defaults of global, and keys of each section named after a subcommand's flag set as defaults of that set.
+// Flags given on the command line still take precedence, so ApplyFlags should be called before Parse, a nil global is skipped.
+// Keys not matching a flag of their set return an error, flags applied report FlagSource as their source.
+// Values of a key are given to a MultiValue flag unsplit, other flags are set to the values joined with ','.
+func (s *Store) ApplyFlags(global FlagSet, subcommands ...FlagSet) (err error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if global != nil {
+		if err = s.applyFlags(GlobalSection, global); err != nil {
+			return err
+		}
+	}
+
+	for _, fs := range subcommands {
+		if err = s.applyFlags(fs.Name(), fs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Sets values of keys in section as defaults of flags in fs.
+func (s *Store) applyFlags(section string, fs FlagSet) (err error) {
+	section = s.sectionName(section)
+
+	var keys []string
+	for key := range s.cfgStore[section].keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		values := s.cfgStore[section].keys[key]
+		f := fs.Lookup(fs.ResolveAlias(key))
+		if f == nil {
+			return fmt.Errorf("[%s] %s: no such flag.", section, key)
+		}
+
+		// Flags holding multiple values take them as is, rather than splitting them again.
+		if mv, ok := f.Value.(MultiValue); ok {
+			mv.SetValues(values)
+		} else {
+			value := strings.Join(values, ",")
+
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+				switch strings.ToLower(value) {
+				case "yes":
+					value = "true"
+				case "no":
+					value = "false"
+				}
+			}
+
+			if err = f.Value.Set(value); err != nil {
+				return fmt.Errorf("[%s] %s: %s", section, key, err)
+			}
+		}
+		f.DefValue = f.Value.String()
+		fs.SetSource(f.Name, FlagSource)
+	}
+
+	return nil
+}
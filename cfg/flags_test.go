@@ -0,0 +1,35 @@
+package cfg
+
+import (
+	"github.com/cmcoffee/go-snuglib/eflag"
+	"reflect"
+	"testing"
+)
+
+func TestApplyFlagsMulti(t *testing.T) {
+	var s Store
+	if err := s.Parse("[global]\nhosts = a, \"b,c\"\npaths = /x, /y\nname = one, two\n"); err != nil {
+		t.Fatal(err)
+	}
+
+	E := eflag.NewFlagSet("test", eflag.ReturnErrorOnly)
+	hosts := E.Multi("hosts", "", "Hosts.")
+	paths := E.Multi("paths", "", "Paths.")
+	E.MultiDelimiter("paths", ';', '\\')
+	name := E.String("name", "", "Name.")
+
+	if err := s.ApplyFlags(E); err != nil {
+		t.Fatal(err)
+	}
+
+	// Values are kept as given, whatever the delimiter of the flag.
+	if want := []string{"a", "b,c"}; !reflect.DeepEqual(*hosts, want) {
+		t.Errorf("hosts = %q, want %q", *hosts, want)
+	}
+	if want := []string{"/x", "/y"}; !reflect.DeepEqual(*paths, want) {
+		t.Errorf("paths = %q, want %q", *paths, want)
+	}
+	if *name != "one,two" {
+		t.Errorf("name = %q, want %q", *name, "one,two")
+	}
+}
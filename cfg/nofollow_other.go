@@ -0,0 +1,15 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly && !netbsd && !openbsd
+// +build !linux,!darwin,!freebsd,!dragonfly,!netbsd,!openbsd
+
+package cfg
+
+import "os"
+
+// Opens file, returning ErrSymlink if file is a symbolic link.
+// Without O_NOFOLLOW the check cannot be made atomic with the open, a link swapped in between is followed.
+func openNoFollow(file string, flag int, perm os.FileMode) (*os.File, error) {
+	if fi, err := os.Lstat(file); err == nil && fi.Mode()&os.ModeSymlink != 0 {
+		return nil, ErrSymlink
+	}
+	return os.OpenFile(file, flag, perm)
+}
@@ -0,0 +1,19 @@
+//go:build linux || darwin || freebsd || dragonfly || netbsd || openbsd
+// +build linux darwin freebsd dragonfly netbsd openbsd
+
+package cfg
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Opens file without following a symbolic link, returning ErrSymlink if file is one.
+func openNoFollow(file string, flag int, perm os.FileMode) (*os.File, error) {
+	f, err := os.OpenFile(file, flag|syscall.O_NOFOLLOW, perm)
+	if errors.Is(err, syscall.ELOOP) || errors.Is(err, syscall.EMLINK) {
+		return nil, ErrSymlink
+	}
+	return f, err
+}
@@ -0,0 +1,58 @@
+package eflag
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Enables matching of unambiguous prefixes of long flags, ie.. --time resolving to --timeout.
+func (s *EFlagSet) AllowAbbreviations(enabled bool) {
+	s.abbrev = enabled
+}
+
+// Expands abbreviated long flags in args to their full names.
+func (s *EFlagSet) expandAbbrev(args []string) ([]string, error) {
+	out := make([]string, 0, len(args))
+
+	for i, a := range args {
+		if a == "--" {
+			return append(out, args[i:]...), nil
+		}
+		if !strings.HasPrefix(a, "--") {
+			out = append(out, a)
+			continue
+		}
+
+		name, value := a[2:], ""
+		if n := strings.IndexByte(name, '='); n > -1 {
+			name, value = name[:n], name[n:]
+		}
+
+		if utf8.RuneCountInString(name) < 2 || name == "help" || s.Lookup(name) != nil {
+			out = append(out, a)
+			continue
+		}
+
+		var candidates []string
+		s.VisitAll(func(f *flag.Flag) {
+			if utf8.RuneCountInString(f.Name) > 1 && strings.HasPrefix(f.Name, name) {
+				candidates = append(candidates, f.Name)
+			}
+		})
+
+		switch len(candidates) {
+		case 0:
+			out = append(out, a)
+		case 1:
+			s.warn("Abbreviated flag --%s expanded to --%s.", name, candidates[0])
+			out = append(out, "--"+candidates[0]+value)
+		default:
+			sort.Strings(candidates)
+			return nil, fmt.Errorf("Ambiguous flag --%s, could be %s.", name, dashedList(candidates, "or"))
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,102 @@
+package eflag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Flag which may only be set along with another.
+type flagDepend struct {
+	name     string
+	requires string
+}
+
+// Requires flag requires to be set whenever flag name is set, ie.. DependsOn("tls-cert", "tls").
+func (s *EFlagSet) DependsOn(name, requires string) {
+	s.depends = append(s.depends, flagDepend{name, requires})
+}
+
+// Requires at least one of the named flags to be set, ie.. OneRequired("user", "token").
+func (s *EFlagSet) OneRequired(names ...string) {
+	if len(names) > 0 {
+		s.one_required = append(s.one_required, names)
+	}
+}
+
+// Returns true if flag, or its alias, was set.
+func (s *EFlagSet) flagIsSet(name string) bool {
+	if s.IsSet(name) {
+		return true
+	}
+	if alias, ok := s.alias[name]; ok {
+		return s.IsSet(alias)
+	}
+	return false
+}
+
+// Joins flag names with dashes, ie.. --user or --token.
+func dashedList(names []string, conj string) string {
+	var list []string
+	for _, n := range names {
+		list = append(list, dashed(n))
+	}
+	if len(list) < 2 {
+		return strings.Join(list, "")
+	}
+	return fmt.Sprintf("%s %s %s", strings.Join(list[:len(list)-1], ", "), conj, list[len(list)-1])
+}
+
+// Checks flag dependencies and required flags after parsing.
+func (s *EFlagSet) checkDepends() error {
+	for _, d := range s.depends {
+		if s.flagIsSet(d.name) && !s.flagIsSet(d.requires) {
+			return fmt.Errorf("%s requires %s to be set.", dashed(d.name), dashed(d.requires))
+		}
+	}
+	for _, names := range s.one_required {
+		var found bool
+		for _, n := range names {
+			if s.flagIsSet(n) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			if len(names) == 1 {
+				return fmt.Errorf("%s is required.", dashed(names[0]))
+			}
+			return fmt.Errorf("One of %s is required.", dashedList(names, "or"))
+		}
+	}
+	return nil
+}
+
+// Returns relationships of flag for help output, ie.. "(requires --tls)".
+func (s *EFlagSet) dependsUsage(name string) string {
+	var notes []string
+	var requires []string
+	for _, d := range s.depends {
+		if d.name == name {
+			requires = append(requires, d.requires)
+		}
+	}
+	if len(requires) > 0 {
+		notes = append(notes, "requires "+dashedList(requires, "and"))
+	}
+	for _, names := range s.one_required {
+		for _, n := range names {
+			if n == name {
+				if len(names) == 1 {
+					notes = append(notes, s.style(ansi_yellow, "required"))
+				} else {
+					notes = append(notes, s.style(ansi_yellow, "one of "+dashedList(names, "or")+" required"))
+				}
+				break
+			}
+		}
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(notes, ", ") + ")"
+}
@@ -0,0 +1,75 @@
+package eflag
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Sources of flag values, as reported by Source and --dump-flags.
+const (
+	SourceDefault = "default" // Value is the flag's default.
+	SourceEnv     = "env"     // Value applied from the environment.
+	SourceConfig  = "config"  // Value applied from a configuration file.
+	SourceCLI     = "cli"     // Value given on the command line.
+)
+
+// Records source of the value of flag name when applied outside of Parse, ie.. SourceConfig or SourceEnv.
+func (s *EFlagSet) SetSource(name, source string) {
+	if s.sources == nil {
+		s.sources = make(map[string]string)
+	}
+	s.sources[s.ResolveAlias(name)] = source
+}
+
+// Returns source of the value of flag name, SourceCLI when set by Parse, SourceDefault when never set.
+func (s *EFlagSet) Source(name string) string {
+	name = s.ResolveAlias(name)
+	if s.flagIsSet(name) {
+		return SourceCLI
+	}
+	if source, ok := s.sources[name]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// Removes hidden --dump-flags from args, returning true if it was found.
+func dumpRequested(args []string) ([]string, bool) {
+	var found bool
+	out := args[:0:0]
+	for i, a := range args {
+		if a == "--" {
+			return append(out, args[i:]...), found
+		}
+		if a == "--dump-flags" || a == "-dump-flags" {
+			found = true
+			continue
+		}
+		out = append(out, a)
+	}
+	return out, found
+}
+
+// Writes final value and source of every flag to w.
+func (s *EFlagSet) dumpFlags(w io.Writer) {
+	var flags []*flag.Flag
+	var width int
+
+	s.VisitAll(func(f *flag.Flag) {
+		if _, alias := s.alias[fmt.Sprintf("-%s-", f.Name)]; alias {
+			return
+		}
+		flags = append(flags, f)
+		if n := utf8.RuneCountInString(dashed(f.Name)); n > width {
+			width = n
+		}
+	})
+
+	for _, f := range flags {
+		name := dashed(f.Name)
+		fmt.Fprintf(w, "  %s%s = %q (%s)\n", name, strings.Repeat(" ", width-utf8.RuneCountInString(name)), f.Value.String(), s.Source(f.Name))
+	}
+}
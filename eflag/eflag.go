@@ -6,12 +6,14 @@
 package eflag
 
 import (
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"strings"
-	"text/tabwriter"
+	"text/template"
 )
 
 // Duplicate flag's ErrorHandling.
@@ -38,7 +40,9 @@ func (s _voidText) Write(p []byte) (n int, err error) {
 }
 
 type multiValue struct {
-	value *[]string
+	value  *[]string
+	delim  rune
+	escape rune
 }
 
 // removes quotation marks from examples.
@@ -53,32 +57,42 @@ func remove_quotes(input string) string {
 
 func (A *multiValue) String() string {
 	if *A.value != nil && len(*A.value) > 0 {
-		return escape_array(*A.value)
+		return escape_values(*A.value, A.delim, A.escape)
 	} else {
 		return ""
 	}
 }
 
 func (A *multiValue) Set(value string) error {
-	*A.value = string_split(value)
+	*A.value = split_values(value, A.delim, A.escape)
 	return nil
 }
 
+// Sets values as given, without splitting on the delimiter, ie.. values of a cfg key applied by cfg.ApplyFlags.
+func (A *multiValue) SetValues(values []string) {
+	*A.value = append([]string{}, values...)
+}
+
 func string_split(input string) (output []string) {
+	return split_values(input, ',', '\\')
+}
+
+// Splits input on delim, unless escaped by escape, an escape of 0 disables escaping.
+func split_values(input string, delim, escape rune) (output []string) {
 	if len(input) == 0 {
 		return
 	}
 	var escaped bool
 	var temp []rune
 	for _, c := range input {
-		switch c {
-		case '\\':
+		switch {
+		case c == escape && escape != 0:
 			if escaped {
 				escaped = false
 			} else {
 				escaped = true
 			}
-		case ',':
+		case c == delim:
 			if !escaped {
 				output = append(output, string(temp[0:]))
 				temp = temp[0:0]
@@ -86,12 +100,12 @@ func string_split(input string) (output []string) {
 				escaped = false
 				temp = append(temp, c)
 			}
-		case '"':
+		case c == '"':
 			escaped = false
 			temp = append(temp, c)
 		default:
 			if escaped {
-				temp = append(temp, '\\')
+				temp = append(temp, escape)
 			}
 			temp = append(temp, c)
 			escaped = false
@@ -101,7 +115,8 @@ func string_split(input string) (output []string) {
 	return
 }
 
-func escape_array(input []string) string {
+// Quotes each value, escaping quotes and delim with escape, then joins with delim.
+func escape_values(input []string, delim, escape rune) string {
 	var (
 		temp   []rune
 		output []string
@@ -109,23 +124,27 @@ func escape_array(input []string) string {
 
 	for _, str := range input {
 		for _, v := range str {
-			switch v {
-			case '"':
-				temp = append(temp, '\\', '"')
-			case ',':
-				temp = append(temp, '\\', ',')
-			default:
-				temp = append(temp, v)
+			if escape != 0 && (v == '"' || v == delim) {
+				temp = append(temp, escape)
 			}
+			temp = append(temp, v)
 		}
 		output = append(output, fmt.Sprintf("\"%s\"", string(temp[0:])))
 		temp = temp[0:0]
 	}
-	return strings.Join(output, ",")
+	return strings.Join(output, string(delim))
 }
 
 func (A *multiValue) Get() interface{} { return []string(*A.value) }
 
+// Returns multiValue to the default value, which was recorded in escaped form.
+func (A *multiValue) reset(def string) {
+	A.Set(def)
+	for i, v := range *A.value {
+		(*A.value)[i] = remove_quotes(v)
+	}
+}
+
 // Array variable, ie.. comma-separated values --flag="test","test2"
 func (E *EFlagSet) Multi(name string, value string, usage string) *[]string {
 	output := new([]string)
@@ -138,7 +157,9 @@ func (E *EFlagSet) MultiVar(p *[]string, name string, value string, usage string
 	*p = string_split(value)
 
 	v := multiValue{
-		value: p,
+		value:  p,
+		delim:  ',',
+		escape: '\\',
 	}
 
 	if len(usage) > 0 {
@@ -147,6 +168,29 @@ func (E *EFlagSet) MultiVar(p *[]string, name string, value string, usage string
 	E.Var(&v, name, usage)
 }
 
+// Sets the delimiter separating values of a Multi flag, and the escape character allowing a delimiter within a value.
+// An escape of 0 disables escaping, values given positionally through CLIArgs are never split.
+func (E *EFlagSet) MultiDelimiter(name string, delim rune, escape rune) {
+	f := E.Lookup(name)
+	if f == nil {
+		return
+	}
+	v, ok := f.Value.(*multiValue)
+	if !ok {
+		return
+	}
+	v.delim = delim
+	v.escape = escape
+	f.DefValue = v.String()
+	f.Usage = strings.Replace(f.Usage, "(multi: comma-separated)", fmt.Sprintf("(multi: %q-separated)", delim), 1)
+}
+
+// Sets the placeholder shown for the flag's value in usage, ie.. --output=PATH.
+// Flags with a placeholder are also eligible to be mapped by CLIArgs.
+func (E *EFlagSet) Placeholder(name string, text string) {
+	E.placeholder[name] = text
+}
+
 // Specifies the name that will be shown for the usage/syntax.
 func (E *EFlagSet) SyntaxName(name string) {
 	E.syntaxName = name
@@ -184,6 +228,7 @@ type EFlagSet struct {
 	Footer        string // Footer presented at end of help.
 	AdaptArgs     bool   // Reorders flags and arguments so flags come first, non-flag arguments second, unescapes arguments with '\' escape character.
 	ShowSyntax    bool   // Display Usage: line, CLIArgs will automatically display usage info.
+	ArgFiles      bool   // Expands '@file' arguments in to the arguments held in file, split with SplitArgsErr.
 	alias         map[string]string
 	out           io.Writer
 	errorHandling ErrorHandling
@@ -191,6 +236,22 @@ type EFlagSet struct {
 	order         []string
 	argMap        []*flag.Flag
 	syntaxName    string
+	placeholder   map[string]string
+	color         bool
+	help_tmpl     *template.Template
+	groups        []flagGroup
+	on_parse      []func() error
+	help_topic    string
+	depends       []flagDepend
+	one_required  [][]string
+	abbrev        bool
+	sources       map[string]string
+	remember      []string
+	prefs         Preferences
+	warnings      []string
+	deprecated    map[string]string
+	descriptions  map[string]string
+	help_layout   HelpLayout
 	*flag.FlagSet
 }
 
@@ -200,6 +261,7 @@ var cmd = EFlagSet{
 	"",
 	false,
 	false,
+	false,
 	make(map[string]string),
 	os.Stderr,
 	ExitOnError,
@@ -207,44 +269,80 @@ var cmd = EFlagSet{
 	make([]string, 0),
 	make([]*flag.Flag, 0),
 	os.Args[0],
+	make(map[string]string),
+	false,
+	nil,
+	nil,
+	nil,
+	"",
+	nil,
+	nil,
+	false,
+	nil,
+	nil,
+	nil,
+	nil,
+	nil,
+	nil,
+	LayoutAuto,
 	flag.NewFlagSet(os.Args[0], flag.ContinueOnError),
 }
 
 var (
-	CLIArgs       = cmd.CLIArgs
-	SyntaxName    = cmd.SyntaxName
-	SetOutput     = cmd.SetOutput
-	PrintDefaults = cmd.PrintDefaults
-	Shorten       = cmd.Shorten
-	String        = cmd.String
-	StringVar     = cmd.StringVar
-	Arg           = cmd.Arg
-	Args          = cmd.Args
-	Bool          = cmd.Bool
-	BoolVar       = cmd.BoolVar
-	Duration      = cmd.Duration
-	DurationVar   = cmd.DurationVar
-	Float64       = cmd.Float64
-	Float64Var    = cmd.Float64Var
-	Int           = cmd.Int
-	IntVar        = cmd.IntVar
-	Int64         = cmd.Int64
-	Int64Var      = cmd.Int64Var
-	Lookup        = cmd.Lookup
-	Multi         = cmd.Multi
-	MultiVar      = cmd.MultiVar
-	NArg          = cmd.NArg
-	NFlag         = cmd.NFlag
-	Name          = cmd.Name
-	Output        = cmd.Output
-	Parsed        = cmd.Parsed
-	Uint          = cmd.Uint
-	UintVar       = cmd.UintVar
-	Uint64        = cmd.Uint64
-	Uint64Var     = cmd.Uint64Var
-	Var           = cmd.Var
-	Visit         = cmd.Visit
-	VisitAll      = cmd.VisitAll
+	AllowAbbreviations = cmd.AllowAbbreviations
+	CLIArgs            = cmd.CLIArgs
+	DependsOn          = cmd.DependsOn
+	Deprecate          = cmd.Deprecate
+	Describe           = cmd.Describe
+	OneRequired        = cmd.OneRequired
+	SyntaxName         = cmd.SyntaxName
+	SetOutput          = cmd.SetOutput
+	SetPreferences     = cmd.SetPreferences
+	ParseString        = cmd.ParseString
+	PrintDefaults      = cmd.PrintDefaults
+	Remember           = cmd.Remember
+	Reparse            = cmd.Reparse
+	SetSource          = cmd.SetSource
+	Shorten            = cmd.Shorten
+	Source             = cmd.Source
+	String             = cmd.String
+	Typed              = cmd.Typed
+	StringVar          = cmd.StringVar
+	Arg                = cmd.Arg
+	Args               = cmd.Args
+	Bool               = cmd.Bool
+	BoolVar            = cmd.BoolVar
+	Duration           = cmd.Duration
+	DurationVar        = cmd.DurationVar
+	Float64            = cmd.Float64
+	Float64Var         = cmd.Float64Var
+	Group              = cmd.Group
+	Int                = cmd.Int
+	IntVar             = cmd.IntVar
+	Int64              = cmd.Int64
+	Int64Var           = cmd.Int64Var
+	Lookup             = cmd.Lookup
+	Merge              = cmd.Merge
+	Multi              = cmd.Multi
+	MultiDelimiter     = cmd.MultiDelimiter
+	MultiVar           = cmd.MultiVar
+	NArg               = cmd.NArg
+	NFlag              = cmd.NFlag
+	Name               = cmd.Name
+	Output             = cmd.Output
+	Parsed             = cmd.Parsed
+	Placeholder        = cmd.Placeholder
+	SetColor           = cmd.SetColor
+	SetHelpLayout      = cmd.SetHelpLayout
+	SetHelpTemplate    = cmd.SetHelpTemplate
+	Uint               = cmd.Uint
+	UintVar            = cmd.UintVar
+	Uint64             = cmd.Uint64
+	Uint64Var          = cmd.Uint64Var
+	Var                = cmd.Var
+	Visit              = cmd.Visit
+	VisitAll           = cmd.VisitAll
+	Warnings           = cmd.Warnings
 )
 
 // Sets the header for usage info.
@@ -306,6 +404,7 @@ func NewFlagSet(name string, errorHandling ErrorHandling) (output *EFlagSet) {
 		"",
 		false,
 		false,
+		false,
 		make(map[string]string),
 		os.Stderr,
 		errorHandling,
@@ -313,6 +412,22 @@ func NewFlagSet(name string, errorHandling ErrorHandling) (output *EFlagSet) {
 		make([]string, 0),
 		make([]*flag.Flag, 0),
 		name,
+		make(map[string]string),
+		false,
+		nil,
+		nil,
+		nil,
+		"",
+		nil,
+		nil,
+		false,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		LayoutAuto,
 		flag.NewFlagSet(name, flag.ContinueOnError),
 	}
 	output.Usage = func() {
@@ -346,101 +461,61 @@ func (s *EFlagSet) VisitAll(fn func(*Flag)) {
 }
 
 // Reads through all flags available and outputs with better formatting.
+// After --help <group>, only flags of that group are shown, after --help <flag>, the flag is shown with its description.
+// Large flag sets list their groups instead of their flags.
 func (s *EFlagSet) PrintDefaults() {
+	s.printDefaults(s.out)
+}
 
-	output := tabwriter.NewWriter(s.out, 1, 1, 3, ' ', 0)
-
-	flag_text := make(map[string]string)
-	var flag_order []string
-	var alias_order []string
-
-	argMap := make(map[string]struct{})
-	for _, v := range s.argMap {
-		argMap[v.Name] = struct{}{}
-	}
+// Writes flags shown by PrintDefaults to w.
+func (s *EFlagSet) printDefaults(w io.Writer) {
+	sections := s.helpSections()
 
-	s.VisitAll(func(flag *flag.Flag) {
-		if flag.Usage == "" {
-			return
-		}
-		if _, ok := argMap[flag.Name]; ok {
-			return
-		}
-		var text []string
-		name := flag.Name
-		alias := s.alias[flag.Name]
-		if alias != "" {
-			if len(alias) > 1 {
-				text = append(text, fmt.Sprintf("  --%s,", alias))
-			} else {
-				text = append(text, fmt.Sprintf("  -%s,", alias))
+	if s.help_topic != "" {
+		for _, section := range sections[1:] {
+			if strings.EqualFold(section.title, s.help_topic) {
+				fmt.Fprintf(w, "\n%s:\n", section.title)
+				s.writeFlags(w, section.flags)
+				return
 			}
 		}
-		space := " "
-		if alias == "" {
-			space = "  "
-		}
-		if len(name) > 1 {
-			text = append(text, fmt.Sprintf("%s--%s", space, name))
-		} else {
-			text = append(text, fmt.Sprintf("%s-%s", space, name))
-		}
-
-		switch flag.DefValue[0] {
-		case '"':
-			if strings.HasPrefix(flag.DefValue, "\"<") && strings.HasSuffix(flag.DefValue, ">\"") {
-				text = append(text, fmt.Sprintf("=%q", flag.DefValue[2:len(flag.DefValue)-2]))
-			} else {
-				text = append(text, fmt.Sprintf("=%s", flag.DefValue))
-			}
-		case '<':
-			if flag.DefValue[len(flag.DefValue)-1] == '>' {
-				text = append(text, fmt.Sprintf("=%q", flag.DefValue[1:len(flag.DefValue)-1]))
-			} else {
-				text = append(text, fmt.Sprintf("=%s", flag.DefValue))
-			}
-		default:
-			if flag.DefValue != "true" && flag.DefValue != "false" {
-				text = append(text, fmt.Sprintf("=%s", flag.DefValue))
-			}
-		}
-
-		text = append(text, fmt.Sprintf("\t%s\n", flag.Usage))
-
-		if alias == "" {
-			flag_text[name] = strings.Join(text[0:], "")
-			flag_order = append(flag_order, name)
-		} else {
-			flag_text[name] = strings.Join(text[0:], "")
-			alias_order = append(alias_order, name)
-		}
-	})
-
-	// Place Aliases first.
-	flag_order = append(alias_order, flag_order[0:]...)
-
-	//OutterLoop:
-	for _, v := range flag_order {
-		for _, o := range s.order {
-			if o == v {
-				for _, name := range s.order {
-					txt, ok := flag_text[name]
-					if ok {
-						fmt.Fprintf(output, txt)
-						delete(flag_text, name)
+		for _, section := range sections {
+			for _, h := range section.flags {
+				if h.name == s.help_topic {
+					s.writeFlags(w, []helpFlag{h})
+					if h.description != "" {
+						fmt.Fprintf(w, "\n")
+						for _, line := range strings.Split(h.description, "\n") {
+							fmt.Fprintf(w, "    %s\n", line)
+						}
 					}
+					return
 				}
 			}
 		}
 	}
-	for _, v := range flag_order {
-		if txt, ok := flag_text[v]; ok {
-			fmt.Fprintf(output, txt)
+
+	if s.compactHelp(sections) {
+		s.writeFlags(w, sections[0].flags)
+		fmt.Fprintf(w, "\nGroups:\n")
+		for _, section := range sections[1:] {
+			if len(section.flags) > 0 {
+				fmt.Fprintf(w, "  %s (%d options)\n", section.title, len(section.flags))
+			}
 		}
+		fmt.Fprintf(w, "\nUse --help <group> to show options of a group.\n")
+		return
 	}
 
-	fmt.Fprintf(output, "  --help\tDisplays this usage information.\n")
-	output.Flush()
+	for _, section := range sections {
+		if section.title != "" {
+			if len(section.flags) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "\n%s:\n", section.title)
+		}
+		s.writeFlags(w, section.flags)
+	}
 }
 
 // Adds a single charachter alias to the command, ie.. --help h
@@ -474,11 +549,199 @@ func (s *EFlagSet) IsSet(name string) bool {
 	return false
 }
 
+// Errors returned by SplitArgsErr.
+var (
+	ErrUnterminatedQuote = errors.New("Unterminated quote in arguments.")
+	ErrTrailingEscape    = errors.New("Trailing backslash in arguments.")
+)
+
+// Splits a command line in to arguments using POSIX shell-like quoting.
+// Single quotes preserve text literally, double quotes allow backslash escaping of '"', '\\', '$' and '`', backslash outside of quotes escapes the next character.
+// An unterminated quote runs to the end of the line and a backslash ending the line is dropped, use SplitArgsErr to have either refused.
+func SplitArgs(line string) []string {
+	args, _ := splitArgs(line)
+	return args
+}
+
+// Splits a command line as SplitArgs does, returning ErrUnterminatedQuote for an unterminated quote and ErrTrailingEscape for a backslash ending the line.
+func SplitArgsErr(line string) (args []string, err error) {
+	if args, err = splitArgs(line); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// Splits line in to arguments, along with any error in its quoting.
+func splitArgs(line string) (args []string, err error) {
+	var (
+		arg     []rune
+		in_arg  bool
+		escaped bool
+		quote   rune
+	)
+
+	for _, c := range line {
+		if escaped {
+			if quote == '"' && !strings.ContainsRune("\\\"$`\n", c) {
+				arg = append(arg, '\\')
+			}
+			// Escaped newlines continue the line.
+			if c != '\n' {
+				arg = append(arg, c)
+			}
+			escaped = false
+			continue
+		}
+		switch quote {
+		case '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				arg = append(arg, c)
+			}
+			continue
+		case '"':
+			switch c {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				arg = append(arg, c)
+			}
+			continue
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			if in_arg {
+				args = append(args, string(arg))
+				arg = arg[0:0]
+				in_arg = false
+			}
+		case '\\':
+			escaped = true
+			in_arg = true
+		case '\'', '"':
+			quote = c
+			in_arg = true
+		default:
+			arg = append(arg, c)
+			in_arg = true
+		}
+	}
+
+	if quote != 0 {
+		err = ErrUnterminatedQuote
+	} else if escaped {
+		err = ErrTrailingEscape
+	}
+
+	if in_arg {
+		args = append(args, string(arg))
+	}
+	return args, err
+}
+
+// Parses flags from a single command line string, split with SplitArgsErr.
+func (s *EFlagSet) ParseString(line string) (err error) {
+	args, err := SplitArgsErr(line)
+	if err != nil {
+		return err
+	}
+	return s.Parse(args)
+}
+
+// Replaces arguments of '@file' with the arguments held in file, split with SplitArgsErr, arguments following "--" are left as is.
+func expandArgFiles(args []string) (expanded []string, err error) {
+	for i, a := range args {
+		if a == "--" {
+			return append(expanded, args[i:]...), nil
+		}
+		if len(a) < 2 || a[0] != '@' {
+			expanded = append(expanded, a)
+			continue
+		}
+		data, err := os.ReadFile(a[1:])
+		if err != nil {
+			return nil, err
+		}
+		file_args, err := SplitArgsErr(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", a[1:], err)
+		}
+		expanded = append(expanded, file_args...)
+	}
+	return expanded, nil
+}
+
+// Resets flags to their defaults and parses args again, returning the names of flags whose values changed.
+// Allows long-running applications to be reconfigured at runtime.
+func (s *EFlagSet) Reparse(args []string) (changed []string, err error) {
+	previous := make(map[string]string)
+
+	s.FlagSet.VisitAll(func(f *Flag) {
+		previous[f.Name] = f.Value.String()
+	})
+
+	// Return values to defaults and register them with a fresh flag.FlagSet to clear parsed state.
+	fs := flag.NewFlagSet(s.FlagSet.Name(), flag.ContinueOnError)
+	s.FlagSet.VisitAll(func(f *Flag) {
+		if v, ok := f.Value.(*multiValue); ok {
+			v.reset(f.DefValue)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+
+	for i, f := range s.argMap {
+		s.argMap[i] = fs.Lookup(f.Name)
+	}
+
+	// Replace in place, as package level functions are bound to the existing flag.FlagSet.
+	*s.FlagSet = *fs
+	s.setFlags = s.setFlags[0:0]
+
+	err = s.Parse(args)
+
+	s.FlagSet.VisitAll(func(f *Flag) {
+		if _, alias := s.alias[fmt.Sprintf("-%s-", f.Name)]; alias {
+			return
+		}
+		if previous[f.Name] != f.Value.String() {
+			changed = append(changed, f.Name)
+		}
+	})
+
+	return
+}
+
+// Adds a function to run after flags are successfully parsed, a returned error is handled as a parse error.
+func (s *EFlagSet) OnParse(fn func() error) {
+	s.on_parse = append(s.on_parse, fn)
+}
+
 // Wraps around the standard flag Parse, adds header and footer.
+// The hidden --dump-flags prints the value and source of each flag after parsing, then exits or returns ErrHelp per the error handling.
 func (s *EFlagSet) Parse(args []string) (err error) {
 	// set usage to empty to prevent unessisary work as we dump the output of flag.
 	s.Usage = func() {}
 
+	var hook_failed bool
+
+	// Expand @argfiles before anything else looks at the arguments.
+	if s.ArgFiles {
+		if args, err = expandArgFiles(args); err != nil {
+			hook_failed = true
+		}
+	}
+
+	s.help_topic = s.helpTopic(args)
+	s.warnings = nil
+
+	// Hidden --dump-flags prints values of flags and their sources once parsed.
+	args, dump := dumpRequested(args)
+
 	var (
 		tmp      []string
 		trailing []string
@@ -525,13 +788,34 @@ func (s *EFlagSet) Parse(args []string) (err error) {
 	stdOut := s.out
 	s.out = voidText
 
-	err = s.FlagSet.Parse(args)
+	// Apply remembered values as defaults.
+	if err == nil {
+		if err = s.loadRemembered(); err != nil {
+			hook_failed = true
+		}
+	}
+
+	// Expand abbreviated flags, an ambiguous flag is reported as is.
+	if s.abbrev && err == nil {
+		if args, err = s.expandAbbrev(args); err != nil {
+			hook_failed = true
+		}
+	}
+
+	if err == nil {
+		err = s.FlagSet.Parse(args)
+	}
 	s.out = stdOut
 
 	val_map := make(map[string]*flag.Value)
 
 	// Remove example text from strings, ie.. <server to connect with>
+	// Flags with a placeholder keep their value, as the example text is kept separately.
 	clear_examples := func(f *flag.Flag) {
+		if _, ok := s.placeholder[f.Name]; ok {
+			val_map[f.Name] = &f.Value
+			return
+		}
 		val := f.Value.String()
 		if (strings.HasPrefix(val, "<") || strings.HasPrefix(val, "\"<")) && (strings.HasSuffix(val, ">") || strings.HasSuffix(val, ">\"")) {
 			f.Value.Set("")
@@ -549,38 +833,47 @@ func (s *EFlagSet) Parse(args []string) (err error) {
 	txt_args := s.FlagSet.Args()
 	multi_set := false
 
+	cli_set := make(map[string]struct{})
+	s.FlagSet.Visit(func(f *flag.Flag) {
+		cli_set[f.Name] = struct{}{}
+	})
+
 	for i, f := range s.argMap {
 		if val, ok := val_map[f.Name]; ok {
 			v := *val
-			if v.String() == "" {
-				if _, ok := v.(*multiValue); ok && !multi_set {
+			_, has_placeholder := s.placeholder[f.Name]
+			_, is_set := cli_set[f.Name]
+			prev := v.String()
+			if (has_placeholder && !is_set || !has_placeholder && prev == "") && num < len(txt_args) {
+				if mv, ok := v.(*multiValue); ok && !multi_set {
 					multi_set = true
 					txt_len := len(txt_args)
+					// Positional values are kept as given, rather than split on the delimiter.
 					// First Argument
 					if i == 0 {
 						if txt_len == 1 {
-							v.Set(txt_args[0])
+							mv.SetValues(txt_args[0:1])
 							num++
 						} else if txt_len > 1 {
 							if e := txt_len - (len(s.argMap) - 1); e > 0 {
-								v.Set(strings.Join(txt_args[0:e], ","))
+								mv.SetValues(txt_args[0:e])
 								num = e
 							} else {
-								v.Set(txt_args[num])
+								mv.SetValues(txt_args[num : num+1])
 								num++
 							}
 						}
 						// Last Argument
 					} else if i == len(s.argMap)-1 {
-						v.Set(strings.Join(txt_args[num:], ","))
+						mv.SetValues(txt_args[num:])
 						num = txt_len - 1
 						// Somewhere in the middle.
 					} else {
 						if x := txt_len - num; x > 1 {
-							v.Set(strings.Join(txt_args[num:txt_len-1], ","))
+							mv.SetValues(txt_args[num : txt_len-1])
 							num = txt_len - 1
 						} else if x > 0 {
-							v.Set(txt_args[txt_len-1])
+							mv.SetValues(txt_args[txt_len-1:])
 							num++
 						}
 					}
@@ -589,7 +882,7 @@ func (s *EFlagSet) Parse(args []string) (err error) {
 					num++
 				}
 			}
-			if v.String() != "" {
+			if has_placeholder && !is_set && v.String() != prev || !has_placeholder && v.String() != "" {
 				mark_set_flags(f)
 			}
 		}
@@ -599,32 +892,54 @@ func (s *EFlagSet) Parse(args []string) (err error) {
 
 	// Implement new Usage function.
 	s.Usage = func() {
-		if s.Header != "" {
-			fmt.Fprintf(s.out, "%s\n", s.Header)
-		}
 		var (
 			arg_names []string
 			has_multi bool
+			syntax    string
 		)
 
 		for _, v := range s.argMap {
 			if val, ok := val_map[v.Name]; ok {
+				def := remove_quotes(v.DefValue)
+				if p, ok := s.placeholder[v.Name]; ok {
+					def = p
+				}
 				if _, ok := (*(val)).(*multiValue); ok && !has_multi {
-					def := remove_quotes(v.DefValue)
 					has_multi = true
 					arg_names = append(arg_names, fmt.Sprintf("%s...", def))
 				} else {
-					arg_names = append(arg_names, remove_quotes(v.DefValue))
+					arg_names = append(arg_names, def)
 				}
 			}
 		}
+
+		if len(arg_names) > 0 {
+			syntax = fmt.Sprintf("Usage: %s [options] %s", s.syntaxName, strings.Join(arg_names, " "))
+		} else if s.ShowSyntax {
+			syntax = fmt.Sprintf("Usage: %s [options]", s.syntaxName)
+		}
+
+		if s.help_tmpl != nil {
+			var options bytes.Buffer
+			s.printDefaults(&options)
+			s.help_tmpl.Execute(s.out, helpData{
+				Name:    s.name,
+				Header:  s.Header,
+				Footer:  s.Footer,
+				Syntax:  syntax,
+				Options: options.String(),
+			})
+			return
+		}
+
+		if s.Header != "" {
+			fmt.Fprintf(s.out, "%s\n", s.Header)
+		}
 		if s.name == "" {
 			fmt.Fprintf(s.out, "Options:\n")
 		} else {
-			if len(arg_names) > 0 {
-				fmt.Fprintf(s.out, "Usage: %s [options] %s\n\n", s.syntaxName, strings.Join(arg_names, " "))
-			} else if s.ShowSyntax {
-				fmt.Fprintf(s.out, "Usage: %s [options]\n\n", s.syntaxName)
+			if syntax != "" {
+				fmt.Fprintf(s.out, "%s\n\n", syntax)
 			}
 			fmt.Fprintf(s.out, "Available '%s' options:\n", s.name)
 		}
@@ -634,9 +949,44 @@ func (s *EFlagSet) Parse(args []string) (err error) {
 		}
 	}
 
+	// Check deprecated flags and flag dependencies, then run functions registered with OnParse.
+	if err == nil {
+		s.checkDeprecated()
+		if err = s.checkDepends(); err != nil {
+			hook_failed = true
+		}
+	}
+	if err == nil {
+		for _, fn := range s.on_parse {
+			if err = fn(); err != nil {
+				hook_failed = true
+				break
+			}
+		}
+	}
+
+	if err == nil {
+		if err = s.saveRemembered(); err != nil {
+			hook_failed = true
+		}
+	}
+
+	if err == nil && dump {
+		fmt.Fprintf(s.out, "Flags:\n")
+		s.dumpFlags(s.out)
+		if s.errorHandling == ExitOnError {
+			os.Exit(0)
+		}
+		return ErrHelp
+	}
+
 	// Implement a new error message.
 	if err != nil {
-		if err != flag.ErrHelp {
+		if hook_failed {
+			if s.errorHandling != ReturnErrorOnly {
+				fmt.Fprintf(s.out, "%s\n\n", err.Error())
+			}
+		} else if err != flag.ErrHelp {
 			errStr := err.Error()
 			cmd := strings.Split(errStr, "-")
 			if len(cmd) > 1 {
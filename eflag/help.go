@@ -0,0 +1,322 @@
+package eflag
+
+import (
+	"flag"
+	"fmt"
+	"golang.org/x/crypto/ssh/terminal"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"unicode/utf8"
+)
+
+// Flag as shown in help output.
+type helpFlag struct {
+	name        string
+	alias       string
+	value       string // Default value or placeholder shown after '=', if any.
+	usage       string
+	description string // Extended description set with Describe, if any.
+}
+
+// Titled group of flags in help output, the first section is untitled.
+type helpSection struct {
+	title string
+	flags []helpFlag
+}
+
+// Data a template set with SetHelpTemplate is executed with.
+type helpData struct {
+	Name    string // Name of flag set.
+	Header  string // Header presented at start of help.
+	Footer  string // Footer presented at end of help.
+	Syntax  string // Usage: line, empty when not shown.
+	Options string // Flags, as listed by PrintDefaults.
+}
+
+// Group of flags shown under a title.
+type flagGroup struct {
+	title string
+	names []string
+}
+
+const (
+	ansi_bold   = "\x1b[1m"
+	ansi_dim    = "\x1b[2m"
+	ansi_yellow = "\x1b[33m"
+	ansi_reset  = "\x1b[0m"
+)
+
+// Layout of flags in help output.
+type HelpLayout int
+
+const (
+	LayoutAuto    HelpLayout = iota // Stacked when output is a terminal narrower than 60 columns, two columns otherwise.
+	LayoutColumns                   // Flag names and usage in two columns.
+	LayoutStacked                   // Usage indented beneath each flag name.
+)
+
+// Terminal width below which LayoutAuto stacks usage beneath flag names.
+const stacked_help_width = 60
+
+// Sets layout of flags in help output, defaults to LayoutAuto.
+func (s *EFlagSet) SetHelpLayout(layout HelpLayout) {
+	s.help_layout = layout
+}
+
+// Returns true if usage should be stacked beneath flag names.
+func (s *EFlagSet) stacked() bool {
+	switch s.help_layout {
+	case LayoutColumns:
+		return false
+	case LayoutStacked:
+		return true
+	}
+	if f, ok := s.out.(*os.File); ok && terminal.IsTerminal(int(f.Fd())) {
+		if width, _, err := terminal.GetSize(int(f.Fd())); err == nil && width > 0 {
+			return width < stacked_help_width
+		}
+	}
+	return false
+}
+
+// Enables ANSI styling of help output, styling is disabled automatically when output is not a terminal or NO_COLOR is set.
+func (s *EFlagSet) SetColor(enabled bool) {
+	s.color = enabled
+}
+
+// Sets a text/template used to render help in place of the default layout.
+// The template is executed with the fields .Name, .Header, .Footer, .Syntax (the Usage: line) and .Options (the flags as listed by PrintDefaults).
+func (s *EFlagSet) SetHelpTemplate(text string) (err error) {
+	tmpl, err := template.New(s.name).Parse(text)
+	if err != nil {
+		return err
+	}
+	s.help_tmpl = tmpl
+	return nil
+}
+
+// Places flags under a titled section in help output.
+func (s *EFlagSet) Group(title string, names ...string) {
+	for i, g := range s.groups {
+		if g.title == title {
+			s.groups[i].names = append(s.groups[i].names, names...)
+			return
+		}
+	}
+	s.groups = append(s.groups, flagGroup{title, names})
+}
+
+// Number of flags at which help lists groups rather than their flags.
+const compact_help_flags = 50
+
+// Returns true if help should list groups rather than all flags.
+func (s *EFlagSet) compactHelp(sections []helpSection) bool {
+	if len(sections) < 2 {
+		return false
+	}
+	var count int
+	for _, section := range sections {
+		count += len(section.flags)
+	}
+	return count >= compact_help_flags
+}
+
+// Sets an extended description of flag name, shown by --help <flag> while usage is kept to a single line.
+func (s *EFlagSet) Describe(name, text string) {
+	if s.descriptions == nil {
+		s.descriptions = make(map[string]string)
+	}
+	s.descriptions[name] = strings.TrimSpace(text)
+}
+
+// Returns group or flag requested with --help=<topic> or --help <topic>.
+func (s *EFlagSet) helpTopic(args []string) string {
+	for i, a := range args {
+		if a == "--" {
+			break
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(a, "-"), "-")
+		if strings.HasPrefix(name, "help=") {
+			topic := strings.TrimPrefix(name, "help=")
+			if f := s.Lookup(s.ResolveAlias(strings.TrimLeft(topic, "-"))); f != nil {
+				return f.Name
+			}
+			return topic
+		}
+		if name == "help" && a != name && i+1 < len(args) {
+			for _, g := range s.groups {
+				if strings.EqualFold(g.title, args[i+1]) {
+					return g.title
+				}
+			}
+			if f := s.Lookup(s.ResolveAlias(strings.TrimLeft(args[i+1], "-"))); f != nil {
+				return f.Name
+			}
+		}
+	}
+	return ""
+}
+
+// Returns true if help output should be styled.
+func (s *EFlagSet) styled() bool {
+	if !s.color || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if f, ok := s.out.(*os.File); ok {
+		return terminal.IsTerminal(int(f.Fd()))
+	}
+	return false
+}
+
+// Wraps text in ANSI code when help output is styled.
+func (s *EFlagSet) style(code, text string) string {
+	if !s.styled() {
+		return text
+	}
+	return code + text + ansi_reset
+}
+
+// Returns value shown after '=' for flag in help output.
+func (s *EFlagSet) helpValue(f *flag.Flag) string {
+	if p, ok := s.placeholder[f.Name]; ok {
+		return p
+	}
+	if len(f.DefValue) == 0 {
+		return ""
+	}
+	switch f.DefValue[0] {
+	case '"':
+		if strings.HasPrefix(f.DefValue, "\"<") && strings.HasSuffix(f.DefValue, ">\"") {
+			return fmt.Sprintf("%q", f.DefValue[2:len(f.DefValue)-2])
+		}
+	case '<':
+		if f.DefValue[len(f.DefValue)-1] == '>' {
+			return fmt.Sprintf("%q", f.DefValue[1:len(f.DefValue)-1])
+		}
+	default:
+		if f.DefValue == "true" || f.DefValue == "false" {
+			return ""
+		}
+	}
+	return f.DefValue
+}
+
+// Collects flags shown in help, aliased flags first, unless an order was specified.
+func (s *EFlagSet) helpFlags() (flags []helpFlag) {
+	var alias_order, flag_order []helpFlag
+
+	argMap := make(map[string]struct{})
+	for _, v := range s.argMap {
+		argMap[v.Name] = struct{}{}
+	}
+
+	s.VisitAll(func(f *flag.Flag) {
+		if f.Usage == "" {
+			return
+		}
+		if _, ok := argMap[f.Name]; ok {
+			return
+		}
+		h := helpFlag{
+			name:        f.Name,
+			alias:       s.alias[f.Name],
+			value:       s.helpValue(f),
+			usage:       f.Usage + s.dependsUsage(f.Name),
+			description: s.descriptions[f.Name],
+		}
+		if h.alias == "" {
+			flag_order = append(flag_order, h)
+		} else {
+			alias_order = append(alias_order, h)
+		}
+	})
+
+	// Place Aliases first.
+	all := append(alias_order, flag_order...)
+
+	used := make(map[string]struct{})
+	for _, name := range s.order {
+		for _, h := range all {
+			if h.name == name {
+				flags = append(flags, h)
+				used[name] = struct{}{}
+			}
+		}
+	}
+	for _, h := range all {
+		if _, ok := used[h.name]; !ok {
+			flags = append(flags, h)
+		}
+	}
+	return
+}
+
+// Organizes flags in to sections, ungrouped flags first.
+func (s *EFlagSet) helpSections() (sections []helpSection) {
+	grouped := make(map[string]int)
+	for i, g := range s.groups {
+		for _, name := range g.names {
+			if _, ok := grouped[name]; !ok {
+				grouped[name] = i + 1
+			}
+		}
+	}
+
+	sections = make([]helpSection, len(s.groups)+1)
+	for i, g := range s.groups {
+		sections[i+1].title = g.title
+	}
+
+	for _, h := range s.helpFlags() {
+		n := grouped[h.name]
+		sections[n].flags = append(sections[n].flags, h)
+	}
+
+	sections[0].flags = append(sections[0].flags, helpFlag{name: "help", usage: "Displays this usage information."})
+	return
+}
+
+// Returns flag name with dashes, ie.. -d or --debug.
+func dashed(name string) string {
+	if utf8.RuneCountInString(name) > 1 {
+		return "--" + name
+	}
+	return "-" + name
+}
+
+// Writes flags in two columns, flag names and usage, or with usage stacked beneath flag names.
+func (s *EFlagSet) writeFlags(w io.Writer, flags []helpFlag) {
+	styled := s.styled()
+
+	// Styled names and values carry the same escape codes on every line, keeping columns aligned.
+	style := func(code, text string) string {
+		if !styled {
+			return text
+		}
+		return code + text + ansi_reset
+	}
+
+	stacked := s.stacked()
+
+	output := tabwriter.NewWriter(w, 1, 1, 3, ' ', 0)
+	defer output.Flush()
+
+	for _, h := range flags {
+		names := dashed(h.name)
+		if h.alias != "" {
+			names = dashed(h.alias) + ", " + names
+		}
+		var value string
+		if h.value != "" {
+			value = "=" + h.value
+		}
+		if stacked {
+			fmt.Fprintf(output, "  %s%s\n      %s\n", style(ansi_bold, names), style(ansi_dim, value), h.usage)
+		} else {
+			fmt.Fprintf(output, "  %s%s\t%s\n", style(ansi_bold, names), style(ansi_dim, value), h.usage)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package eflag
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Imports flags of other under prefix, ie.. --db.timeout for flag timeout merged with prefix "db", an empty prefix keeps their names.
+// Imported flags share their values with other, so variables bound by a library are set when the host set is parsed.
+// Placeholders, descriptions, deprecations, dependencies and groups of other are carried over, single character aliases are not.
+// If any imported name is already defined, an error is returned and no flags are imported.
+func (s *EFlagSet) Merge(other *EFlagSet, prefix string) (err error) {
+	rename := func(name string) string {
+		if prefix == "" {
+			return name
+		}
+		return prefix + "." + name
+	}
+
+	var flags []*Flag
+	other.VisitAll(func(f *Flag) {
+		if _, ok := other.alias[fmt.Sprintf("-%s-", f.Name)]; ok {
+			return
+		}
+		flags = append(flags, f)
+	})
+
+	var conflicts []string
+	for _, f := range flags {
+		if name := rename(f.Name); s.Lookup(name) != nil || name == "help" {
+			conflicts = append(conflicts, name)
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return fmt.Errorf("Cannot merge flags of %s, %s already defined.", other.Name(), dashedList(conflicts, "and"))
+	}
+
+	for _, f := range flags {
+		name := rename(f.Name)
+		s.Var(f.Value, name, f.Usage)
+		s.Lookup(name).DefValue = f.DefValue
+		if p, ok := other.placeholder[f.Name]; ok {
+			s.Placeholder(name, p)
+		}
+		if d, ok := other.descriptions[f.Name]; ok {
+			s.Describe(name, d)
+		}
+		if m, ok := other.deprecated[f.Name]; ok {
+			s.Deprecate(name, m)
+		}
+		if src, ok := other.sources[f.Name]; ok {
+			s.SetSource(name, src)
+		}
+	}
+
+	for _, d := range other.depends {
+		s.DependsOn(rename(d.name), rename(d.requires))
+	}
+	for _, names := range other.one_required {
+		var renamed []string
+		for _, n := range names {
+			renamed = append(renamed, rename(n))
+		}
+		s.OneRequired(renamed...)
+	}
+	for _, g := range other.groups {
+		var renamed []string
+		for _, n := range g.names {
+			renamed = append(renamed, rename(n))
+		}
+		s.Group(g.title, renamed...)
+	}
+
+	return nil
+}
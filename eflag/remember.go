@@ -0,0 +1,69 @@
+package eflag
+
+import (
+	"fmt"
+)
+
+// Source of values applied from preferences set with SetPreferences.
+const SourceRemembered = "remembered"
+
+// Preferences holds values of remembered flags, keyed by flag name, a kvlite.Table satisfies it.
+type Preferences interface {
+	Get(key string, output interface{}) (found bool, err error)
+	Set(key string, value interface{}) (err error)
+}
+
+// Sets table holding values of flags named with Remember, keyed by flag name.
+// Flag sets sharing a table should not remember flags of the same name.
+func (s *EFlagSet) SetPreferences(prefs Preferences) {
+	s.prefs = prefs
+}
+
+// Remembers values of the named flags given on the command line in the table set with SetPreferences, using them as defaults on the next run.
+func (s *EFlagSet) Remember(names ...string) {
+	s.remember = append(s.remember, names...)
+}
+
+// Applies remembered values as defaults of their flags.
+func (s *EFlagSet) loadRemembered() (err error) {
+	if s.prefs == nil {
+		return nil
+	}
+	for _, name := range s.remember {
+		f := s.Lookup(s.ResolveAlias(name))
+		if f == nil {
+			continue
+		}
+		var value string
+		found, err := s.prefs.Get(f.Name, &value)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		if err = f.Value.Set(value); err != nil {
+			return fmt.Errorf("Remembered value of %s is invalid: %s", dashed(f.Name), err)
+		}
+		f.DefValue = f.Value.String()
+		s.SetSource(f.Name, SourceRemembered)
+	}
+	return nil
+}
+
+// Stores values of remembered flags given on the command line.
+func (s *EFlagSet) saveRemembered() (err error) {
+	if s.prefs == nil {
+		return nil
+	}
+	for _, name := range s.remember {
+		f := s.Lookup(s.ResolveAlias(name))
+		if f == nil || !s.flagIsSet(f.Name) {
+			continue
+		}
+		if err = s.prefs.Set(f.Name, f.Value.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package eflag
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var converters struct {
+	mutex sync.RWMutex
+	types map[reflect.Type]reflect.Value
+}
+
+var error_type = reflect.TypeOf((*error)(nil)).Elem()
+
+// Registers converter for flags declared with Typed, converter must be a func(string) (T, error).
+// ie.. RegisterType(func(s string) (net.IPNet, error) { ... })
+func RegisterType(converter interface{}) {
+	fn := reflect.ValueOf(converter)
+	t := fn.Type()
+
+	if t.Kind() != reflect.Func || t.NumIn() != 1 || t.In(0).Kind() != reflect.String || t.NumOut() != 2 || t.Out(1) != error_type {
+		panic(fmt.Sprintf("eflag: RegisterType requires func(string) (T, error), got %s", t))
+	}
+
+	converters.mutex.Lock()
+	defer converters.mutex.Unlock()
+
+	if converters.types == nil {
+		converters.types = make(map[reflect.Type]reflect.Value)
+	}
+	converters.types[t.Out(0)] = fn
+}
+
+// flag.Value using a registered converter.
+type typedValue struct {
+	ptr  reflect.Value
+	conv reflect.Value
+	text string
+}
+
+func (t *typedValue) Set(value string) error {
+	out := t.conv.Call([]reflect.Value{reflect.ValueOf(value)})
+	if err, _ := out[1].Interface().(error); err != nil {
+		return err
+	}
+	t.ptr.Elem().Set(out[0])
+	t.text = value
+	return nil
+}
+
+func (t *typedValue) String() string {
+	if t.text != "" || !t.ptr.IsValid() || t.ptr.Elem().IsZero() {
+		return t.text
+	}
+	return fmt.Sprint(t.ptr.Elem().Interface())
+}
+
+func (t *typedValue) Get() interface{} {
+	return t.ptr.Elem().Interface()
+}
+
+// Defines a flag for a type registered with RegisterType, p must be a pointer to the registered type.
+// The current value of p is used as the default.
+func (s *EFlagSet) Typed(p interface{}, name string, usage string) {
+	ptr := reflect.ValueOf(p)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		panic(fmt.Sprintf("eflag: Typed requires a pointer for flag %s", name))
+	}
+
+	converters.mutex.RLock()
+	conv, ok := converters.types[ptr.Type().Elem()]
+	converters.mutex.RUnlock()
+
+	if !ok {
+		panic(fmt.Sprintf("eflag: no converter registered for type %s of flag %s", ptr.Type().Elem(), name))
+	}
+
+	s.Var(&typedValue{ptr: ptr, conv: conv}, name, usage)
+}
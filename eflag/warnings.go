@@ -0,0 +1,38 @@
+package eflag
+
+import (
+	"fmt"
+)
+
+// Marks flag name as deprecated, using it adds a warning with message to Warnings.
+func (s *EFlagSet) Deprecate(name, message string) {
+	if s.deprecated == nil {
+		s.deprecated = make(map[string]string)
+	}
+	s.deprecated[name] = message
+}
+
+// Returns non-fatal issues found during the last Parse, such as deprecated flags used or abbreviated flags expanded.
+func (s *EFlagSet) Warnings() []string {
+	return append([]string{}, s.warnings...)
+}
+
+// Adds a warning to be returned by Warnings.
+func (s *EFlagSet) warn(format string, args ...interface{}) {
+	s.warnings = append(s.warnings, fmt.Sprintf(format, args...))
+}
+
+// Adds warnings for deprecated flags that were set.
+func (s *EFlagSet) checkDeprecated() {
+	s.VisitAll(func(f *Flag) {
+		message, ok := s.deprecated[f.Name]
+		if !ok || !s.flagIsSet(f.Name) {
+			return
+		}
+		if message != "" {
+			s.warn("Flag %s is deprecated: %s", dashed(f.Name), message)
+		} else {
+			s.warn("Flag %s is deprecated.", dashed(f.Name))
+		}
+	})
+}
@@ -7,9 +7,7 @@ retract [v1.0.0, v1.0.21]
 require (
 	github.com/boltdb/bolt v1.3.1
 	golang.org/x/crypto v0.22.0
+	golang.org/x/sys v0.19.0
 )
 
-require (
-	golang.org/x/sys v0.19.0 // indirect
-	golang.org/x/term v0.19.0 // indirect
-)
+require golang.org/x/term v0.19.0 // indirect
@@ -0,0 +1,203 @@
+package iotimeout
+
+import (
+	. "github.com/cmcoffee/go-snuglib/xsync"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Timeout Reader which reads ahead of the consumer in to a buffer.
+type bufferedReader struct {
+	src        io.Reader
+	opts       Options
+	mutex      sync.Mutex
+	cond       *sync.Cond
+	buf        []byte
+	size       int
+	err        error
+	filling    bool
+	fill_start time.Time
+	on_wait    atomic.Value
+	clock      Clock
+	done       chan struct{}
+	close_once sync.Once
+}
+
+// Buffered Timeout Reader: Reads ahead up to size bytes from source, only time spent waiting on source counts toward timeout.
+// A consumer reading slowly while the buffer is full does not cause a timeout.
+func NewBufferedReader(source io.Reader, size int, timeout time.Duration) io.ReadCloser {
+	return NewBufferedReaderWithOptions(source, size, Options{Idle: timeout})
+}
+
+// Buffered Timeout Reader: Reads ahead up to size bytes from source, with timers configured by opts.
+// Idle counts only time spent waiting on source, Total and Context limit reading as a whole.
+// Close stops the timer and closes source if it is an io.Closer, which ends a read in progress on most sources.
+func NewBufferedReaderWithOptions(source io.Reader, size int, opts Options) io.ReadCloser {
+	if source == nil {
+		return nil
+	}
+	if size <= 0 {
+		size = 4096
+	}
+	if opts.Resolution <= 0 {
+		opts.Resolution = time.Second
+	}
+	t := &bufferedReader{
+		src:   source,
+		opts:  opts,
+		size:  size,
+		buf:   make([]byte, 0, size),
+		clock: opts.clock(),
+		done:  make(chan struct{}),
+	}
+	t.cond = sync.NewCond(&t.mutex)
+
+	go t.fill()
+	go t.start_timer()
+
+	return t
+}
+
+// Fills buffer from source while there is room.
+func (t *bufferedReader) fill() {
+	chunk := make([]byte, t.size)
+	for {
+		t.mutex.Lock()
+		for len(t.buf) >= t.size && t.err == nil {
+			t.cond.Wait()
+		}
+		if t.err != nil {
+			t.mutex.Unlock()
+			return
+		}
+		room := t.size - len(t.buf)
+		t.filling = true
+		t.fill_start = t.clock.Now()
+		t.mutex.Unlock()
+
+		n, err := t.src.Read(chunk[:room])
+
+		t.mutex.Lock()
+		t.filling = false
+		if t.err == nil {
+			t.buf = append(t.buf, chunk[:n]...)
+			t.err = err
+		}
+		t.cond.Broadcast()
+		t.mutex.Unlock()
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Halts reader with err, unless it has already stopped.
+func (t *bufferedReader) halt(err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.err == nil {
+		t.err = err
+		t.cond.Broadcast()
+	}
+}
+
+// Timer for buffer fills.
+func (t *bufferedReader) start_timer() {
+	resolution := t.opts.Resolution
+	idle_timeout := t.opts.Idle.Round(resolution)
+
+	var ctx_done <-chan struct{}
+	if t.opts.Context != nil {
+		ctx_done = t.opts.Context.Done()
+	}
+
+	ticker := t.clock.NewTicker(resolution)
+	defer ticker.Stop()
+
+	start := t.clock.Now()
+
+	for {
+		select {
+		case <-ticker.C():
+		case <-ctx_done:
+			t.halt(t.opts.Context.Err())
+			return
+		case <-t.done:
+			return
+		}
+
+		now := t.clock.Now()
+
+		if t.opts.Total > 0 && now.Sub(start) >= t.opts.Total {
+			t.halt(ErrTimeout)
+			return
+		}
+
+		t.mutex.Lock()
+		if t.err != nil {
+			t.mutex.Unlock()
+			return
+		}
+		var idle time.Duration
+		if t.filling {
+			idle = now.Sub(t.fill_start).Round(resolution)
+			if idle_timeout > 0 && idle >= idle_timeout {
+				t.err = ErrTimeout
+				t.cond.Broadcast()
+				t.mutex.Unlock()
+				return
+			}
+		}
+		t.mutex.Unlock()
+
+		if idle > 0 {
+			if fn, _ := t.on_wait.Load().(func(time.Duration)); fn != nil {
+				fn(idle)
+			}
+		}
+	}
+}
+
+// Sets function called each Resolution the source is not providing bytes, with the time spent idle, until timeout is reached.
+// The function is called from the timer, so should not block.
+func (t *bufferedReader) SetOnWait(fn func(idle time.Duration)) {
+	t.on_wait.Store(fn)
+}
+
+// Reads from buffer, waiting on source when empty.
+func (t *bufferedReader) Read(p []byte) (n int, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.err == ErrClosed {
+		return 0, ErrClosed
+	}
+
+	for len(t.buf) == 0 && t.err == nil {
+		t.cond.Wait()
+	}
+
+	if len(t.buf) > 0 {
+		n = copy(p, t.buf)
+		t.buf = t.buf[:copy(t.buf, t.buf[n:])]
+		t.cond.Broadcast()
+		return n, nil
+	}
+
+	return 0, t.err
+}
+
+// Stops the timer and closes source if it is an io.Closer, later reads return ErrClosed.
+func (t *bufferedReader) Close() (err error) {
+	t.close_once.Do(func() {
+		t.halt(ErrClosed)
+		close(t.done)
+		if c, ok := t.src.(io.Closer); ok {
+			err = c.Close()
+		}
+	})
+	return err
+}
@@ -0,0 +1,68 @@
+package iotimeout
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestBufferedReader(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 100)
+
+	r := NewBufferedReader(bytes.NewReader(data), 64, time.Minute)
+	defer r.Close()
+
+	if got, err := io.ReadAll(r); err != nil || !bytes.Equal(got, data) {
+		t.Errorf("ReadAll returned %d bytes, %v", len(got), err)
+	}
+}
+
+func TestBufferedIdleTimeout(t *testing.T) {
+	clock := fakeClock()
+
+	r := NewBufferedReaderWithOptions(stalled(t), 0, Options{Idle: 2 * time.Second, Resolution: 500 * time.Millisecond, Clock: clock})
+	defer r.Close()
+
+	calls := make(chan time.Duration, 10)
+	SetOnWait(r, func(idle time.Duration) { calls <- idle })
+
+	res, elapsed := advanceUntil(t, clock, 500*time.Millisecond, read(r, 1))
+	if !errors.Is(res.err, ErrTimeout) {
+		t.Fatalf("Read returned %v, want ErrTimeout", res.err)
+	}
+	if elapsed < 2*time.Second {
+		t.Errorf("Read timed out after %s, before the idle timeout", elapsed)
+	}
+
+	// SetOnWait is called each Resolution rather than each second.
+	if idle := <-calls; idle != 500*time.Millisecond {
+		t.Errorf("SetOnWait first called with %s, want %s", idle, 500*time.Millisecond)
+	}
+}
+
+func TestBufferedClose(t *testing.T) {
+
+	before := runtime.NumGoroutine()
+
+	src, w := io.Pipe()
+	defer w.Close()
+
+	r := NewBufferedReader(src, 0, time.Minute)
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Read(make([]byte, 1)); !errors.Is(err, ErrClosed) {
+		t.Errorf("Read after Close returned %v, want ErrClosed", err)
+	}
+
+	// The fill goroutine and timer both exit once closed.
+	for i := 0; runtime.NumGoroutine() > before; i++ {
+		if i == 1000 {
+			t.Fatalf("%d goroutines remain after Close, want %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -5,48 +5,111 @@
 package iotimeout
 
 import (
+	"context"
 	"errors"
 	. "github.com/cmcoffee/go-snuglib/xsync"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
-var ErrTimeout = errors.New("Timeout reached while waiting for bytes.")
+var (
+	ErrTimeout = errors.New("Timeout reached while waiting for bytes.")
+	ErrClosed  = errors.New("Read from closed reader.")
+)
 
 const (
 	waiting = 1 << iota
 	halted
 )
 
+func init() {
+	RegisterBits(map[uint64]string{waiting: "waiting", halted: "halted"})
+}
+
+// Options configures a timeout reader.
+type Options struct {
+	Idle       time.Duration   // Time without bytes flowing before ErrTimeout, rounded to Resolution, 0 disables.
+	Total      time.Duration   // Overall time allowed for reading before ErrTimeout, 0 disables.
+	Resolution time.Duration   // Interval between timer checks, defaults to one second.
+	Context    context.Context // Reads fail with the context's error once it is done.
+	Clock      Clock           // Clock timers are driven by, ie.. an xsync.FakeClock in tests, defaults to RealClock.
+}
+
+// Returns clock of opts.
+func (opts Options) clock() Clock {
+	if opts.Clock == nil {
+		return RealClock
+	}
+	return opts.Clock
+}
+
 // Timer for io tranfer
-func start_timer(timeout time.Duration, flag *BitFlag, input chan []byte, expired chan struct{}) {
-	timeout_seconds := int64(timeout.Round(time.Second).Seconds())
+func (t *readCloser) start_timer() {
+	resolution := t.opts.Resolution
+	if resolution <= 0 {
+		resolution = time.Second
+	}
 
-	var cnt int64
+	idle_timeout := t.opts.Idle.Round(resolution)
+
+	var done <-chan struct{}
+	if t.opts.Context != nil {
+		done = t.opts.Context.Done()
+	}
+
+	ticker := t.clock.NewTicker(resolution)
+	defer ticker.Stop()
+
+	start := t.clock.Now()
+
+	var idle time.Duration
 
 	for {
-		time.Sleep(time.Second)
-		if flag.Has(halted) {
-			input <- nil
+		select {
+		case <-ticker.C():
+		case <-done:
+			t.expire(t.opts.Context.Err(), true)
+			return
+		}
+
+		if t.flag.Has(halted) {
+			t.input <- nil
 			break
 		}
 
-		if flag.Has(waiting) {
-			cnt++
-			if timeout_seconds > 0 && cnt >= timeout_seconds {
-				flag.Set(halted)
-				expired <- struct{}{}
-				input <- nil
-				break
+		if t.opts.Total > 0 && t.clock.Now().Sub(start) >= t.opts.Total {
+			t.expire(ErrTimeout, true)
+			return
+		}
+
+		if t.flag.Has(waiting) {
+			idle += resolution
+			if idle_timeout > 0 && idle >= idle_timeout {
+				t.expire(ErrTimeout, false)
+				return
+			}
+			if fn, _ := t.on_wait.Load().(func(time.Duration)); fn != nil {
+				fn(idle)
 			}
 		} else {
-			cnt = 0
-			flag.Set(waiting)
+			idle = 0
+			t.flag.Set(waiting)
 		}
 	}
 }
 
+// Halts reader with err, a final error is also returned by reads after the timer halts.
+func (t *readCloser) expire(err error, final bool) {
+	if final {
+		t.err.Store(err)
+	}
+	t.flag.Set(halted)
+	t.expired <- err
+	t.input <- nil
+}
+
 type resp struct {
 	n   int
 	err error
@@ -55,11 +118,15 @@ type resp struct {
 // Timeout Reader.
 type readCloser struct {
 	src     io.ReadCloser
+	opts    Options
 	flag    BitFlag
 	input   chan []byte
 	output  chan resp
-	expired chan struct{}
+	expired chan error
+	err     atomic.Value
 	mutex   sync.Mutex
+	on_wait atomic.Value
+	clock   Clock
 }
 
 type reader struct {
@@ -72,21 +139,33 @@ func (r reader) Close() (err error) {
 
 // Timeout Reader: Adds a time to io.Reader
 func NewReader(source io.Reader, timeout time.Duration) io.Reader {
-	return NewReadCloser(reader{source}, timeout)
+	return NewReadCloserWithOptions(reader{source}, Options{Idle: timeout})
 }
 
 // Timeout ReadCloser: Adds a timer to io.ReadCloser
 func NewReadCloser(source io.ReadCloser, timeout time.Duration) io.ReadCloser {
+	return NewReadCloserWithOptions(source, Options{Idle: timeout})
+}
+
+// Timeout Reader: Adds timers configured by opts to io.Reader
+func NewReaderWithOptions(source io.Reader, opts Options) io.Reader {
+	return NewReadCloserWithOptions(reader{source}, opts)
+}
+
+// Timeout ReadCloser: Adds timers configured by opts to io.ReadCloser
+func NewReadCloserWithOptions(source io.ReadCloser, opts Options) io.ReadCloser {
 	t := new(readCloser)
 	if source == nil {
 		return source
 	}
 	t.src = source
+	t.opts = opts
+	t.clock = opts.clock()
 	t.input = make(chan []byte, 2)
 	t.output = make(chan resp, 1)
-	t.expired = make(chan struct{}, 1)
+	t.expired = make(chan error, 1)
 
-	go start_timer(timeout, &t.flag, t.input, t.expired)
+	go t.start_timer()
 
 	go func() {
 		var (
@@ -106,12 +185,28 @@ func NewReadCloser(source io.ReadCloser, timeout time.Duration) io.ReadCloser {
 	return t
 }
 
+// Sets function called each Resolution no bytes are flowing, with the time spent idle, until timeout is reached.
+// The function is called from the timer, so should not block.
+func (t *readCloser) SetOnWait(fn func(idle time.Duration)) {
+	t.on_wait.Store(fn)
+}
+
+// Sets function called each Resolution no bytes are flowing on a reader created by NewReader or NewReadCloser, every second unless set by Options.
+func SetOnWait(r io.Reader, fn func(idle time.Duration)) {
+	if w, ok := r.(interface{ SetOnWait(func(time.Duration)) }); ok {
+		w.SetOnWait(fn)
+	}
+}
+
 // Time Sensitive Read function.
 func (t *readCloser) Read(p []byte) (n int, err error) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 
 	if t.flag.Has(halted) {
+		if err, _ := t.err.Load().(error); err != nil {
+			return 0, err
+		}
 		return t.src.Read(p)
 	}
 
@@ -124,9 +219,9 @@ func (t *readCloser) Read(p []byte) (n int, err error) {
 	case data := <-t.output:
 		n = data.n
 		err = data.err
-	case <-t.expired:
+	case err = <-t.expired:
 		t.flag.Set(halted)
-		return -1, ErrTimeout
+		return -1, err
 	}
 	if err != nil {
 		t.flag.Set(halted)
@@ -0,0 +1,252 @@
+package iotimeout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	. "github.com/cmcoffee/go-snuglib/xsync"
+	"io"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Returns a FakeClock to drive timers with.
+func fakeClock() *FakeClock {
+	return NewFakeClock(epoch)
+}
+
+// Returns a source which blocks until the test ends.
+func stalled(t *testing.T) io.ReadCloser {
+	t.Helper()
+	r, w := io.Pipe()
+	t.Cleanup(func() { w.Close() })
+	return r
+}
+
+// Result of a Read.
+type result struct {
+	n   int
+	err error
+}
+
+// Reads from r in the background.
+func read(r io.Reader, size int) <-chan result {
+	done := make(chan result, 1)
+	go func() {
+		n, err := r.Read(make([]byte, size))
+		done <- result{n, err}
+	}()
+	return done
+}
+
+// Advances clock by step until done delivers, returning the result and time advanced.
+func advanceUntil(t *testing.T, clock *FakeClock, step time.Duration, done <-chan result) (result, time.Duration) {
+	t.Helper()
+	clock.BlockUntil(1)
+	start := clock.Now()
+	for i := 0; i < 1000; i++ {
+		select {
+		case r := <-done:
+			return r, clock.Now().Sub(start)
+		case <-time.After(time.Millisecond):
+			clock.Advance(step)
+		}
+	}
+	t.Fatal("read did not return")
+	return result{}, 0
+}
+
+func TestIdleTimeout(t *testing.T) {
+	clock := fakeClock()
+
+	r := NewReadCloserWithOptions(stalled(t), Options{Idle: 3 * time.Second, Clock: clock})
+	res, elapsed := advanceUntil(t, clock, time.Second, read(r, 1))
+
+	if !errors.Is(res.err, ErrTimeout) {
+		t.Fatalf("Read returned %v, want ErrTimeout", res.err)
+	}
+	if elapsed < 3*time.Second {
+		t.Errorf("Read timed out after %s, before the idle timeout", elapsed)
+	}
+}
+
+func TestIdleResetByData(t *testing.T) {
+	clock := fakeClock()
+
+	src := &gated{started: make(chan struct{}), data: make(chan byte)}
+	r := NewReadCloserWithOptions(src, Options{Idle: 3 * time.Second, Clock: clock})
+
+	// Bytes arriving within the idle timeout keep the reader alive.
+	for i := 0; i < 10; i++ {
+		done := read(r, 1)
+		<-src.started
+		clock.Advance(time.Second)
+		time.Sleep(time.Millisecond)
+		src.data <- 'x'
+		if res := <-done; res.err != nil || res.n != 1 {
+			t.Fatalf("Read %d returned %d, %v", i, res.n, res.err)
+		}
+	}
+}
+
+func TestTotalTimeout(t *testing.T) {
+	clock := fakeClock()
+
+	src := io.NopCloser(infinite{})
+	r := NewReadCloserWithOptions(src, Options{Total: 5 * time.Second, Resolution: 500 * time.Millisecond, Clock: clock})
+
+	clock.BlockUntil(1)
+	start := clock.Now()
+
+	var err error
+	for i := 0; i < 1000 && err == nil; i++ {
+		_, err = r.Read(make([]byte, 1))
+		clock.Advance(100 * time.Millisecond)
+		time.Sleep(100 * time.Microsecond)
+	}
+
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("Read returned %v, want ErrTimeout", err)
+	}
+	if elapsed := clock.Now().Sub(start); elapsed < 5*time.Second {
+		t.Errorf("Read timed out after %s, before the total timeout", elapsed)
+	}
+	if _, err = r.Read(make([]byte, 1)); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Read after total timeout returned %v, want ErrTimeout", err)
+	}
+}
+
+func TestContext(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewReadCloserWithOptions(stalled(t), Options{Context: ctx})
+
+	done := read(r, 1)
+	cancel()
+
+	select {
+	case res := <-done:
+		if !errors.Is(res.err, context.Canceled) {
+			t.Fatalf("Read returned %v, want context.Canceled", res.err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Read did not return once context was cancelled")
+	}
+
+	if _, err := r.Read(make([]byte, 1)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Read after cancel returned %v, want context.Canceled", err)
+	}
+}
+
+func TestSetOnWait(t *testing.T) {
+	clock := fakeClock()
+
+	r := NewReadCloserWithOptions(stalled(t), Options{Resolution: 250 * time.Millisecond, Clock: clock})
+
+	calls := make(chan time.Duration, 10)
+	SetOnWait(r, func(idle time.Duration) { calls <- idle })
+
+	read(r, 1)
+	clock.BlockUntil(1)
+
+	// The first tick starts the idle timer, each following tick reports the time spent idle.
+	var want time.Duration
+	for i := 0; i < 100 && want < time.Second; i++ {
+		clock.Advance(250 * time.Millisecond)
+		select {
+		case idle := <-calls:
+			want += 250 * time.Millisecond
+			if idle != want {
+				t.Fatalf("SetOnWait called with %s, want %s", idle, want)
+			}
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if want < time.Second {
+		t.Errorf("SetOnWait reported %s idle, want %s", want, time.Second)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	data := []byte("hello, world")
+
+	r := NewReader(bytes.NewReader(data), time.Minute)
+	got, err := io.ReadAll(r)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("ReadAll of NewReader returned %q, %v", got, err)
+	}
+
+	if _, ok := r.(io.Closer); !ok {
+		t.Errorf("NewReader returned %T, not closable", r)
+	}
+}
+
+func TestNewReaderTimeout(t *testing.T) {
+	// The wrappers time out as a reader with the timeout as its Idle option, as tested above.
+	for _, r := range []io.Reader{NewReader(stalled(t), 2*time.Second), NewReadCloser(stalled(t), 2*time.Second)} {
+		rc := r.(*readCloser)
+		if rc.opts != (Options{Idle: 2 * time.Second}) {
+			t.Errorf("%T created with options %+v", r, rc.opts)
+		}
+		rc.Close()
+	}
+}
+
+func TestNewReadCloser(t *testing.T) {
+	src := &closer{Reader: bytes.NewReader([]byte("data"))}
+
+	r := NewReadCloser(src, time.Minute)
+	if got, err := io.ReadAll(r); err != nil || string(got) != "data" {
+		t.Errorf("ReadAll of NewReadCloser returned %q, %v", got, err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !src.closed {
+		t.Error("Close of NewReadCloser did not close source")
+	}
+
+	if r := NewReadCloser(nil, time.Minute); r != nil {
+		t.Errorf("NewReadCloser of nil returned %v", r)
+	}
+}
+
+// Reader which always returns a byte.
+type infinite struct{}
+
+func (infinite) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	p[0] = 'x'
+	return 1, nil
+}
+
+// ReadCloser signalling started as each Read begins, returning bytes sent on data.
+type gated struct {
+	started chan struct{}
+	data    chan byte
+}
+
+func (g *gated) Read(p []byte) (int, error) {
+	g.started <- struct{}{}
+	p[0] = <-g.data
+	return 1, nil
+}
+
+func (g *gated) Close() error {
+	return nil
+}
+
+// ReadCloser recording whether it was closed.
+type closer struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closer) Close() error {
+	c.closed = true
+	return nil
+}
@@ -0,0 +1,61 @@
+package iotimeout
+
+import (
+	"errors"
+	. "github.com/cmcoffee/go-snuglib/xsync"
+	"io"
+	"time"
+)
+
+var (
+	ErrReadTimeout  = errors.New("Timeout reached while waiting for writer.")
+	ErrWriteTimeout = errors.New("Timeout reached while waiting for reader.")
+)
+
+// PipeReader is the read half of a pipe, failing with ErrReadTimeout when the writer stalls.
+type PipeReader struct {
+	*io.PipeReader
+	w       *io.PipeWriter
+	timeout time.Duration
+	clock   Clock
+}
+
+// PipeWriter is the write half of a pipe, failing with ErrWriteTimeout when the reader stalls.
+type PipeWriter struct {
+	*io.PipeWriter
+	r       *io.PipeReader
+	timeout time.Duration
+	clock   Clock
+}
+
+// Pipe creates a synchronous in-memory pipe, like io.Pipe, where a Read or Write blocked longer than timeout closes the pipe.
+// The blocked side fails with ErrReadTimeout or ErrWriteTimeout, and its counterpart fails with io.ErrClosedPipe.
+func Pipe(timeout time.Duration) (*PipeReader, *PipeWriter) {
+	return PipeWithClock(timeout, RealClock)
+}
+
+// PipeWithClock creates a pipe like Pipe, whose timeouts are timed by clock, ie.. an xsync.FakeClock in tests.
+func PipeWithClock(timeout time.Duration, clock Clock) (*PipeReader, *PipeWriter) {
+	pr, pw := io.Pipe()
+	return &PipeReader{pr, pw, timeout, clock}, &PipeWriter{pw, pr, timeout, clock}
+}
+
+// Time Sensitive Read function.
+func (r *PipeReader) Read(p []byte) (n int, err error) {
+	if r.timeout <= 0 {
+		return r.PipeReader.Read(p)
+	}
+	t := r.clock.AfterFunc(r.timeout, func() { r.w.CloseWithError(ErrReadTimeout) })
+	defer t.Stop()
+	return r.PipeReader.Read(p)
+}
+
+// Time Sensitive Write function.
+func (w *PipeWriter) Write(p []byte) (n int, err error) {
+	if w.timeout <= 0 {
+		return w.PipeWriter.Write(p)
+	}
+	t := w.clock.AfterFunc(w.timeout, func() { w.r.CloseWithError(ErrWriteTimeout) })
+	defer t.Stop()
+	return w.PipeWriter.Write(p)
+}
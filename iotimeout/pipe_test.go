@@ -0,0 +1,63 @@
+package iotimeout
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestPipeReadTimeout(t *testing.T) {
+	clock := fakeClock()
+	r, w := PipeWithClock(time.Second, clock)
+	defer w.Close()
+
+	done := read(r, 1)
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+
+	if res := <-done; !errors.Is(res.err, ErrReadTimeout) {
+		t.Errorf("Read returned %v, want ErrReadTimeout", res.err)
+	}
+	if _, err := w.Write([]byte("x")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Write after read timeout returned %v, want io.ErrClosedPipe", err)
+	}
+}
+
+func TestPipeWriteTimeout(t *testing.T) {
+	clock := fakeClock()
+	r, w := PipeWithClock(time.Second, clock)
+	defer r.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Write([]byte("x"))
+		done <- err
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+
+	if err := <-done; !errors.Is(err, ErrWriteTimeout) {
+		t.Errorf("Write returned %v, want ErrWriteTimeout", err)
+	}
+}
+
+func TestPipe(t *testing.T) {
+	clock := fakeClock()
+	r, w := PipeWithClock(time.Second, clock)
+
+	go func() {
+		w.Write([]byte("hello"))
+		w.Close()
+	}()
+
+	if got, err := io.ReadAll(r); err != nil || string(got) != "hello" {
+		t.Errorf("ReadAll returned %q, %v", got, err)
+	}
+
+	// Timers of reads and writes which finished in time are stopped.
+	clock.Advance(time.Minute)
+	if _, err := r.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Read after close returned %v, want io.EOF", err)
+	}
+}
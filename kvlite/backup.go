@@ -0,0 +1,111 @@
+package kvlite
+
+import (
+	"github.com/boltdb/bolt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Opens BoltDB backed kvlite.Store, taking a backup of the database in dir on the first open of each day.
+// While the Store remains open, the date is checked hourly and a backup taken once the day changes, so long-running processes are backed up daily too.
+// Backups are named after the database and date, ie.. app.db.20240131.bak, only the newest keep backups are retained, keep of 0 retains all.
+// An empty dir places backups beside the database, the error of a failed backup taken while open is returned by Close.
+func OpenWithBackups(filename, dir string, keep int, padlock ...byte) (Store, error) {
+	db, err := openStore(filename, Options{}, padlock)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir == "" {
+		dir = filepath.Dir(filename)
+	}
+
+	prefix := filepath.Join(dir, filepath.Base(filename))
+
+	if err = db.dailyBackup(prefix, keep); err != nil {
+		db.close()
+		return nil, err
+	}
+
+	db.scheduleBackups(prefix, keep)
+
+	return rootStore(db), nil
+}
+
+// Checks hourly for a new day to back up, until the database is closed.
+func (K *boltDB) scheduleBackups(prefix string, keep int) {
+	stop := make(chan struct{})
+	done := make(chan struct{})
+
+	K.stop_backups = func() error {
+		close(stop)
+		<-done
+		return K.backup_err
+	}
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := K.dailyBackup(prefix, keep); err != nil {
+					K.backup_err = err
+				}
+			}
+		}
+	}()
+}
+
+// Takes a hot backup of the database to prefix, dated with today's date, unless one exists, then removes backups beyond keep.
+func (K *boltDB) dailyBackup(prefix string, keep int) (err error) {
+	if err = os.MkdirAll(filepath.Dir(prefix), 0700); err != nil {
+		return err
+	}
+
+	name := prefix + "." + time.Now().Format("20060102") + ".bak"
+
+	if _, err = os.Stat(name); os.IsNotExist(err) {
+		tmp := name + ".tmp"
+		err = K.db.View(func(tx *bolt.Tx) error {
+			return tx.CopyFile(tmp, 0600)
+		})
+		if err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		if err = os.Rename(tmp, name); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	if keep < 1 {
+		return nil
+	}
+
+	backups, err := filepath.Glob(prefix + ".[0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9].bak")
+	if err != nil {
+		return err
+	}
+
+	// Dated names sort oldest first.
+	sort.Strings(backups)
+
+	for len(backups) > keep {
+		if err = os.Remove(backups[0]); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		backups = backups[1:]
+	}
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package kvlite
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Key of cached entry, table is named in full, including its namespace.
+type cacheKey struct {
+	table string
+	key   string
+}
+
+type cacheEntry struct {
+	cacheKey
+	value []byte
+}
+
+// LRU shared by all namespaces of a cached store.
+type lruCache struct {
+	mutex   sync.Mutex
+	max     int
+	order   *list.List
+	entries map[cacheKey]*list.Element
+	gen     uint64 // Incremented by each write, so reads begun before it are not cached.
+}
+
+// Returns current write generation of cache.
+func (c *lruCache) generation() uint64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.gen
+}
+
+// Retrieves stored value, marking it as recently used.
+func (c *lruCache) get(k cacheKey) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if e, ok := c.entries[k]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(*cacheEntry).value, true
+	}
+	return nil, false
+}
+
+// Stores value read at generation gen, unless a write has completed since, evicting least recently used entries over max.
+func (c *lruCache) put(k cacheKey, value []byte, gen uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.gen != gen {
+		return
+	}
+	if e, ok := c.entries[k]; ok {
+		e.Value.(*cacheEntry).value = value
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[k] = c.order.PushFront(&cacheEntry{k, value})
+	for c.order.Len() > c.max {
+		e := c.order.Back()
+		delete(c.entries, e.Value.(*cacheEntry).cacheKey)
+		c.order.Remove(e)
+	}
+}
+
+// Removes entries of keys and tables changed by a transaction.
+func (c *lruCache) invalidate(changes *cacheChanges) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.gen++
+	for _, k := range changes.keys {
+		if e, ok := c.entries[k]; ok {
+			delete(c.entries, k)
+			c.order.Remove(e)
+		}
+	}
+	if len(changes.tables) == 0 {
+		return
+	}
+	dropped := make(map[string]bool)
+	for _, t := range changes.tables {
+		dropped[t] = true
+	}
+	for k, e := range c.entries {
+		if dropped[k.table] {
+			delete(c.entries, k)
+			c.order.Remove(e)
+		}
+	}
+}
+
+// Removes all entries.
+func (c *lruCache) clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.order.Init()
+	c.entries = make(map[cacheKey]*list.Element)
+}
+
+// Backend serving reads from an in-memory LRU, with writes passed through to the backing database.
+type cached struct {
+	backend
+	cache *lruCache
+}
+
+// Cached wraps backing with an in-memory LRU of up to maxEntries values.
+// Writes are passed through to backing, while values changed or dropped by any operation are removed from cache once it completes.
+// Values are cached as stored, so values stored with CryptSet remain encrypted in memory.
+// The cache is shared by all namespaces of the returned Store, other Stores of the same database bypass it.
+// If maxEntries is less than 1, backing is returned as is.
+func Cached(backing Store, maxEntries int) Store {
+	if maxEntries < 1 {
+		return backing
+	}
+	d := backing.namespace()
+	return &substore{d.prefix, &cached{
+		backend: d.db,
+		cache: &lruCache{
+			max:     maxEntries,
+			order:   list.New(),
+			entries: make(map[cacheKey]*list.Element),
+		},
+	}}
+}
+
+// Runs fn in a read-only transaction, serving values read from cache.
+// The generation is taken before the transaction begins, so values read from a snapshot older than a completed write are not cached.
+func (c *cached) view(fn func(tx txn) error) error {
+	gen := c.cache.generation()
+	return c.backend.view(func(tx txn) error {
+		return fn(cachedTx{tx, c.cache, gen, nil})
+	})
+}
+
+// Runs fn in a read-write transaction, removing values it changed from cache once it completes.
+func (c *cached) update(fn func(tx txn) error) (err error) {
+	changes := new(cacheChanges)
+	err = c.backend.update(func(tx txn) error {
+		return fn(cachedTx{tx, c.cache, 0, changes})
+	})
+	c.cache.invalidate(changes)
+	return err
+}
+
+// Clears cache and closes the backing store.
+func (c *cached) close() (err error) {
+	c.cache.clear()
+	return c.backend.close()
+}
+
+// Keys and tables changed by a transaction.
+type cacheChanges struct {
+	keys   []cacheKey
+	tables []string
+}
+
+// Transaction through cache, changes is nil for read-only transactions.
+type cachedTx struct {
+	txn
+	cache   *lruCache
+	gen     uint64
+	changes *cacheChanges
+}
+
+func (tx cachedTx) table(name string) txTable {
+	if t := tx.txn.table(name); t != nil {
+		return cachedTable{t, name, tx}
+	}
+	return nil
+}
+
+func (tx cachedTx) createTable(name string) (txTable, error) {
+	t, err := tx.txn.createTable(name)
+	if err != nil {
+		return nil, err
+	}
+	return cachedTable{t, name, tx}, nil
+}
+
+func (tx cachedTx) dropTable(name string) error {
+	if tx.changes != nil {
+		tx.changes.tables = append(tx.changes.tables, name)
+	}
+	return tx.txn.dropTable(name)
+}
+
+// Table of transaction through cache.
+type cachedTable struct {
+	txTable
+	name string
+	tx   cachedTx
+}
+
+// Retrieves value from cache, falling back to the backing table, read-write transactions always read the backing table.
+func (t cachedTable) get(key []byte) []byte {
+	if t.tx.changes != nil {
+		return t.txTable.get(key)
+	}
+	k := cacheKey{t.name, string(key)}
+	if v, ok := t.tx.cache.get(k); ok {
+		return v
+	}
+	v := t.txTable.get(key)
+	if v != nil {
+		t.tx.cache.put(k, append([]byte{}, v...), t.tx.gen)
+	}
+	return v
+}
+
+func (t cachedTable) put(key, value []byte) error {
+	if t.tx.changes != nil {
+		t.tx.changes.keys = append(t.tx.changes.keys, cacheKey{t.name, string(key)})
+	}
+	return t.txTable.put(key, value)
+}
+
+func (t cachedTable) delete(key []byte) error {
+	if t.tx.changes != nil {
+		t.tx.changes.keys = append(t.tx.changes.keys, cacheKey{t.name, string(key)})
+	}
+	return t.txTable.delete(key)
+}
@@ -0,0 +1,206 @@
+package kvlite
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ErrNotSlice is returned by ListRange when output is not a pointer to a slice.
+var ErrNotSlice = errors.New("Output must be a pointer to a slice.")
+
+// Names of tables holding items of lists and members of sets, within a namespace named after their table.
+const (
+	list_prefix = "List:"
+	set_prefix  = "Set:"
+)
+
+// Returns key of list item at index.
+func listIndex(index int64) []byte {
+	return []byte(fmt.Sprintf("%020d", index))
+}
+
+// Returns full names of table, and of the table holding items of the collection at key.
+func collectionTables(d *substore, table, prefix, key string) (name, items string, err error) {
+	if name, err = d.apply_prefix(table); err != nil {
+		return "", "", err
+	}
+	if items, err = newSub(d.prefix, table, d.db).apply_prefix(prefix + key); err != nil {
+		return "", "", err
+	}
+	return name, items, nil
+}
+
+// Reads count stored at key of table, found is false if there is no count.
+func readCount(db backend, t txTable, key string) (count int64, found bool, err error) {
+	if t == nil {
+		return 0, false, nil
+	}
+	v := t.get([]byte(key))
+	if v == nil {
+		return 0, false, nil
+	}
+	return count, true, db.codec().decode(v, &count)
+}
+
+// Adds to collection at key of table within transaction, add stores the item, returning false if nothing was added.
+func addItem(db backend, tx txn, name, items, key string, add func(items txTable, count int64) (bool, error)) (added bool, err error) {
+	t, err := tx.createTable(name)
+	if err != nil {
+		return false, err
+	}
+	count, found, err := readCount(db, t, key)
+	if err != nil {
+		return false, err
+	}
+	// Clear items left behind by an Unset of the collection.
+	if !found {
+		if err = tx.dropTable(items); err != nil {
+			return false, err
+		}
+	}
+	it, err := tx.createTable(items)
+	if err != nil {
+		return false, err
+	}
+	if added, err = add(it, count); err != nil || !added {
+		return false, err
+	}
+	v, err := db.codec().record(count+1, plain_value)
+	if err != nil {
+		return false, err
+	}
+	return true, t.put([]byte(key), v)
+}
+
+// ListAppend appends item to the list at key of table, storing each item under its own key so the list is never rewritten.
+// The length of the list is stored at key, while items are kept in a namespace named after table, so are removed along with it by Drop.
+// Each append runs in a single transaction.
+func ListAppend(db Store, table, key string, item interface{}) (err error) {
+	d := db.namespace()
+	defer d.track("ListAppend", table, key, time.Now(), &err)
+
+	name, items, err := collectionTables(d, table, list_prefix, key)
+	if err != nil {
+		return err
+	}
+
+	v, err := d.db.codec().record(item, plain_value)
+	if err != nil {
+		return err
+	}
+
+	return d.db.update(func(tx txn) error {
+		_, err := addItem(d.db, tx, name, items, key, func(items txTable, length int64) (bool, error) {
+			return true, items.put(listIndex(length), v)
+		})
+		return err
+	})
+}
+
+// ListRange decodes up to count items of the list at key of table from index start in to output, a pointer to a slice, a negative count reads to the end.
+func ListRange(db Store, table, key string, start, count int, output interface{}) (err error) {
+	d := db.namespace()
+	defer d.track("ListRange", table, key, time.Now(), &err)
+
+	out := reflect.ValueOf(output)
+	if out.Kind() != reflect.Ptr || out.Elem().Kind() != reflect.Slice {
+		return ErrNotSlice
+	}
+	slice := out.Elem()
+
+	name, items, err := collectionTables(d, table, list_prefix, key)
+	if err != nil {
+		return err
+	}
+
+	if start < 0 {
+		start = 0
+	}
+
+	err = d.db.view(func(tx txn) error {
+		length, found, err := readCount(d.db, tx.table(name), key)
+		if err != nil || !found {
+			return err
+		}
+		it := tx.table(items)
+		if it == nil {
+			return nil
+		}
+
+		end := length
+		if count >= 0 && int64(start)+int64(count) < end {
+			end = int64(start) + int64(count)
+		}
+
+		i := int64(start)
+		c := it.cursor()
+		for k, data := c.Seek(listIndex(i)); k != nil && i < end; k, data = c.Next() {
+			v := reflect.New(slice.Type().Elem())
+			if err = d.db.codec().decode(data, v.Interface()); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, v.Elem())
+			i++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	out.Elem().Set(slice)
+	return nil
+}
+
+// SetAdd adds member to the set at key of table, returning false if it was already a member.
+// Each member is stored under its own key, while the number of members is stored at key, both in a single transaction.
+func SetAdd(db Store, table, key, member string) (added bool, err error) {
+	d := db.namespace()
+	defer d.track("SetAdd", table, key, time.Now(), &err)
+
+	name, members, err := collectionTables(d, table, set_prefix, key)
+	if err != nil {
+		return false, err
+	}
+
+	v, err := d.db.codec().record(true, plain_value)
+	if err != nil {
+		return false, err
+	}
+
+	err = d.db.update(func(tx txn) (err error) {
+		added, err = addItem(d.db, tx, name, members, key, func(members txTable, size int64) (bool, error) {
+			if members.get([]byte(member)) != nil {
+				return false, nil
+			}
+			return true, members.put([]byte(member), v)
+		})
+		return err
+	})
+	return added, err
+}
+
+// SetHas checks for member in the set at key of table.
+func SetHas(db Store, table, key, member string) (found bool, err error) {
+	d := db.namespace()
+	defer d.track("SetHas", table, key, time.Now(), &err)
+
+	name, members, err := collectionTables(d, table, set_prefix, key)
+	if err != nil {
+		return false, err
+	}
+
+	err = d.db.view(func(tx txn) error {
+		t := tx.table(name)
+		if t == nil || t.get([]byte(key)) == nil {
+			return nil
+		}
+		if m := tx.table(members); m != nil {
+			found = m.get([]byte(member)) != nil
+		}
+		return nil
+	})
+	return found, err
+}
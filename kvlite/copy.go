@@ -0,0 +1,69 @@
+package kvlite
+
+// Copies tables from src to dst, if no tables are given all tables are copied, including those of nested namespaces.
+// Encrypted values are decrypted with the key of src and encrypted again with the key of dst.
+func Copy(src, dst Store, tables ...string) (err error) {
+	var names []string
+
+	from, to := src.namespace(), dst.namespace()
+
+	if len(tables) == 0 {
+		if names, err = from.buckets(false); err != nil {
+			return err
+		}
+	} else {
+		for _, t := range tables {
+			if t == "" {
+				return ErrEmptyName
+			}
+			names = append(names, escapeName(t))
+		}
+	}
+
+	for _, name := range names {
+		records, err := from.export(name)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			continue
+		}
+		if err = to.restore(name, records); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Decrypts encrypted parts of stored value, keeping its marker so it can be sealed again by another encoder.
+func (e encoder) unseal(input []byte) ([]byte, error) {
+	output := append([]byte{}, input...)
+	switch input[0] {
+	case crypt_value:
+		copy(output[1:], e.decrypt(input[1:]))
+	case partial_value:
+		_, secret, err := splitPartial(output)
+		if err != nil {
+			return nil, err
+		}
+		copy(secret, e.decrypt(secret))
+	}
+	return output, nil
+}
+
+// Encrypts parts of an unsealed value marked for encryption.
+func (e encoder) seal(input []byte) ([]byte, error) {
+	output := append([]byte{}, input...)
+	switch input[0] {
+	case crypt_value:
+		copy(output[1:], e.encrypt(input[1:]))
+	case partial_value:
+		_, secret, err := splitPartial(output)
+		if err != nil {
+			return nil, err
+		}
+		copy(secret, e.encrypt(secret))
+	}
+	return output, nil
+}
@@ -0,0 +1,100 @@
+package kvlite
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+)
+
+// ErrDropToken is returned by DropSafe when the confirmation token does not match the table.
+var ErrDropToken = errors.New("Confirmation token does not match table, refusing to drop.")
+
+// Prefix of drop records in the KVLite bucket.
+const drop_record = "Drop:"
+
+// DropRecord is the audit entry written by DropSafe.
+type DropRecord struct {
+	Table string    // Table dropped, with namespaces separated by '/'.
+	User  string    // User running the process.
+	Host  string    // Host running the process.
+	Time  time.Time // Time table was dropped.
+	Keys  int       // Number of keys in the table when dropped.
+}
+
+// Returns confirmation token DropSafe requires to drop table.
+func DropToken(table string) string {
+	sum := sha256.Sum256([]byte("DropSafe:" + table))
+	return fmt.Sprintf("%x", sum[:4])
+}
+
+// Returns audit entries written by DropSafe, oldest first.
+func DropHistory(store Store) (records []DropRecord, err error) {
+	db := store.namespace().db
+	err = db.view(func(tx txn) error {
+		bucket := tx.table("KVLite")
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.cursor()
+		for k, v := c.Seek([]byte(drop_record)); k != nil && strings.HasPrefix(string(k), drop_record); k, v = c.Next() {
+			var record DropRecord
+			if err := db.codec().decode(v, &record); err != nil {
+				return err
+			}
+			records = append(records, record)
+		}
+		return nil
+	})
+	return records, err
+}
+
+// DropSafe drops table of db once token matches DropToken(table), recording the drop in the KVLite bucket shared by all namespaces.
+// The record is written in the same transaction as the drop, so a table is never dropped without one.
+func DropSafe(db Store, table, token string) (err error) {
+	d := db.namespace()
+	defer d.track("DropSafe", table, "", time.Now(), &err)
+	if token != DropToken(table) {
+		return ErrDropToken
+	}
+	name := d.name(table)
+	if table, err = d.apply_prefix(table); err != nil {
+		return err
+	}
+
+	record := DropRecord{
+		Table: name,
+		Time:  time.Now(),
+	}
+	if u, err := user.Current(); err == nil {
+		record.User = u.Username
+	} else {
+		record.User = os.Getenv("USER")
+	}
+	record.Host, _ = os.Hostname()
+
+	return d.db.update(func(tx txn) error {
+		if bucket := tx.table(table); bucket != nil {
+			record.Keys = countKeys(bucket)
+		}
+
+		v, err := d.db.codec().record(&record, plain_value)
+		if err != nil {
+			return err
+		}
+		meta, err := tx.createTable("KVLite")
+		if err != nil {
+			return err
+		}
+		if err = meta.put([]byte(fmt.Sprintf("%s%020d", drop_record, record.Time.UnixNano())), v); err != nil {
+			return err
+		}
+
+		return dropTables(tx, func(t string) bool {
+			return t == table || strings.HasPrefix(t, table+string(sepr))
+		})
+	})
+}
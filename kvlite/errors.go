@@ -0,0 +1,27 @@
+package kvlite
+
+import (
+	"fmt"
+)
+
+// StoreError records the operation, table and key of a failed store operation.
+type StoreError struct {
+	Op    string // Operation, ie.. Get or Set.
+	Table string // Table, with namespaces separated by '/'.
+	Key   string // Key, or key prefix, empty for operations on whole tables.
+	Err   error  // Underlying error.
+}
+
+func (e *StoreError) Error() string {
+	if e.Table == "" {
+		return fmt.Sprintf("%s failed: %s", e.Op, e.Err)
+	}
+	if e.Key == "" {
+		return fmt.Sprintf("%s of table '%s' failed: %s", e.Op, e.Table, e.Err)
+	}
+	return fmt.Sprintf("%s of '%s' in table '%s' failed: %s", e.Op, e.Key, e.Table, e.Err)
+}
+
+func (e *StoreError) Unwrap() error {
+	return e.Err
+}
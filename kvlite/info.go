@@ -0,0 +1,101 @@
+package kvlite
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Info holds usage statistics of a database, persisted in its reserved KVLite bucket.
+type Info struct {
+	Created    time.Time // Time database was first opened.
+	LastOpened time.Time // Time database was last opened.
+	Opens      int64     // Number of times database has been opened.
+	Reads      int64     // Read operations, including those of the current session.
+	Writes     int64     // Write operations, including those of the current session.
+}
+
+// Operation counts of a database since it was opened.
+type counters struct {
+	reads  int64
+	writes int64
+}
+
+// Operations which modify the database.
+var write_ops = map[string]bool{
+	"Set":             true,
+	"CryptSet":        true,
+	"CryptSetPartial": true,
+	"Unset":           true,
+	"UnsetPrefix":     true,
+	"Drop":            true,
+	"DropSafe":        true,
+	"DropPrefix":      true,
+	"Push":            true,
+	"Pop":             true,
+	"Ack":             true,
+	"Requeue":         true,
+	"Add":             true,
+	"Prune":           true,
+	"Downsample":      true,
+	"ListAppend":      true,
+	"SetAdd":          true,
+}
+
+// Counts completed operation as a read or write.
+func (c *counters) add(op string) {
+	if write_ops[op] {
+		atomic.AddInt64(&c.writes, 1)
+	} else {
+		atomic.AddInt64(&c.reads, 1)
+	}
+}
+
+// Adds counts of current session to info.
+func (c *counters) apply(info Info) Info {
+	info.Reads += atomic.LoadInt64(&c.reads)
+	info.Writes += atomic.LoadInt64(&c.writes)
+	return info
+}
+
+// Returns usage statistics of database.
+func (K *boltDB) info() (info Info, err error) {
+	if _, err = getValue(K, "KVLite", "Info", &info); err != nil {
+		return info, err
+	}
+	return K.counters.apply(info), nil
+}
+
+// Records database being opened.
+func (K *boltDB) openInfo() (err error) {
+	var info Info
+	if _, err = getValue(K, "KVLite", "Info", &info); err != nil {
+		return err
+	}
+	now := time.Now()
+	if info.Created.IsZero() {
+		info.Created = now
+	}
+	info.LastOpened = now
+	info.Opens++
+	K.track_info = true
+	return setValue(K, "KVLite", "Info", &info, plain_value)
+}
+
+// Persists counts of current session, called on Close.
+func (K *boltDB) closeInfo() (err error) {
+	info, err := K.info()
+	if err != nil {
+		return err
+	}
+	K.counters = counters{}
+	return setValue(K, "KVLite", "Info", &info, plain_value)
+}
+
+// Returns usage statistics of memory store.
+func (K *memStore) info() (info Info, err error) {
+	return K.counters.apply(Info{
+		Created:    K.created,
+		LastOpened: K.created,
+		Opens:      1,
+	}), nil
+}
@@ -7,9 +7,8 @@ import (
 	"crypto/sha256"
 	"encoding/gob"
 	"errors"
-	"fmt"
 	"github.com/boltdb/bolt"
-	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,34 +24,51 @@ type Store interface {
 	Sub(name string) Store
 	// SyncStore Creates a new bucket for shared tenants.
 	Bucket(name string) Store
+	// Queue provides a persistent FIFO queue, kept within its own namespace.
+	Queue(name string) *Queue
 	// Drop drops the specified table.
 	Drop(table string) (err error)
+	// DropPrefix drops all tables with names beginning with prefix, in a single transaction.
+	DropPrefix(prefix string) (err error)
 	// CountKeys provides a total of keys in table.
 	CountKeys(table string) (count int, err error)
 	// Keys provides a listing of all keys in table.
 	Keys(table string) (keys []string, err error)
+	// KeysPage provides up to limit keys of table in order following after_key, and the after_key of the next page, empty on the last page.
+	KeysPage(table, after_key string, limit int) (keys []string, next string, err error)
 	// CryptSet encrypts the value within the key/value pair in table.
 	CryptSet(table, key string, value interface{}) (err error)
+	// CryptSetPartial encrypts only the struct fields tagged `kvlite:"encrypt"` within the key/value pair in table.
+	CryptSetPartial(table, key string, value interface{}) (err error)
 	// Set sets the key/value pair in table.
 	Set(table, key string, value interface{}) (err error)
 	// Unset deletes the key/value pair in table.
 	Unset(table, key string) (err error)
+	// UnsetPrefix deletes all key/value pairs in table with keys beginning with prefix, in a single transaction.
+	UnsetPrefix(table, prefix string) (err error)
 	// Get retrieves value at key in table.
 	Get(table, key string, output interface{}) (found bool, err error)
 	// Close closes the kvliter.Store.
 	Close() (err error)
-	// Buckets lists all bucket namespaces, limit_depth limits to first-level buckets
-	buckets(limit_depth bool) (stores []string, err error)
+	// SetObserver sets a function called after each operation, for metrics or slow-operation logging.
+	SetObserver(fn Observer)
+	// Info provides usage statistics of the database.
+	Info() (info Info, err error)
+	// namespace returns the namespace of the store, through which helpers reach its backend.
+	namespace() *substore
 }
 
 // Table Interface follows the Main Store Interface, but directly to a table.
 type Table interface {
 	Keys() (keys []string, err error)
+	KeysPage(after_key string, limit int) (keys []string, next string, err error)
 	CountKeys() (count int, err error)
 	Set(key string, value interface{}) (err error)
 	CryptSet(key string, value interface{}) (err error)
+	CryptSetPartial(key string, value interface{}) (err error)
 	Get(key string, value interface{}) (found bool, err error)
 	Unset(key string) (err error)
+	UnsetPrefix(prefix string) (err error)
 	Drop() (err error)
 }
 
@@ -69,6 +85,10 @@ func (s focused) Keys() (keys []string, err error) {
 	return s.store.Keys(s.table)
 }
 
+func (s focused) KeysPage(after_key string, limit int) (keys []string, next string, err error) {
+	return s.store.KeysPage(s.table, after_key, limit)
+}
+
 func (s focused) CountKeys() (count int, err error) {
 	return s.store.CountKeys(s.table)
 }
@@ -81,48 +101,73 @@ func (s focused) CryptSet(key string, value interface{}) (err error) {
 	return s.store.CryptSet(s.table, key, value)
 }
 
+func (s focused) CryptSetPartial(key string, value interface{}) (err error) {
+	return s.store.CryptSetPartial(s.table, key, value)
+}
+
 func (s focused) Unset(key string) (err error) {
 	return s.store.Unset(s.table, key)
 }
 
+func (s focused) UnsetPrefix(prefix string) (err error) {
+	return s.store.UnsetPrefix(s.table, prefix)
+}
+
 func (s focused) Drop() (err error) {
 	return s.store.Drop(s.table)
 }
 
 // Bolt Backend
 type boltDB struct {
-	db      *bolt.DB
-	encoder encoder
+	db         *bolt.DB
+	encoder    encoder
+	sync_every int64
+	unsynced   int64
+	track_info bool
+	// Stops backups scheduled by OpenWithBackups, returning the error of a failed backup.
+	stop_backups func() error
+	backup_err   error
+	observed
+}
+
+// Options tunes the bolt database opened by OpenWithOptions.
+type Options struct {
+	Timeout         time.Duration // Time to wait for the database lock before ErrLocked, defaults to one second.
+	NoSync          bool          // Skips fsync after each write, a system crash may lose recent writes.
+	NoGrowSync      bool          // Skips fsync when the database file grows.
+	InitialMmapSize int           // Initial size of the memory map, a large enough map keeps reads from blocking writes.
+	SyncEvery       int           // Syncs once every n writes instead of after each write, implies NoSync.
+}
+
+// Runs fn in a read-only transaction.
+func (K *boltDB) view(fn func(tx txn) error) error {
+	return K.db.View(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
 }
 
-type encoder []byte
-
-// Get all buckets on system.
-func (K *boltDB) buckets(limit_depth bool) (buckets []string, err error) {
-	bmap := make(map[string]struct{})
-
-	err = K.db.View(func(tx *bolt.Tx) error {
-		add_bucket := func(name []byte, b *bolt.Bucket) error {
-			name_str := string(name)
-			if name_str == "KVLite" {
-				return nil
-			}
-			if !limit_depth {
-				buckets = append(buckets, name_str)
-			} else {
-				name_str = strings.Split(name_str, string(sepr))[0]
-				if _, ok := bmap[name_str]; !ok {
-					bmap[name_str] = struct{}{}
-					buckets = append(buckets, name_str)
-				}
-			}
-			return nil
-		}
-		return tx.ForEach(add_bucket)
+// Runs fn in a read-write transaction, when NoSync is set writes are counted and synced every sync_every writes.
+func (K *boltDB) update(fn func(tx txn) error) (err error) {
+	err = K.db.Update(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
 	})
-	return buckets, err
+	if err != nil || !K.db.NoSync {
+		return err
+	}
+	if n := atomic.AddInt64(&K.unsynced, 1); K.sync_every > 0 && n >= K.sync_every {
+		return K.sync()
+	}
+	return nil
 }
 
+// Flushes writes not yet synced to disk, pending writes are also synced on Close.
+func (K *boltDB) sync() error {
+	atomic.StoreInt64(&K.unsynced, 0)
+	return K.db.Sync()
+}
+
+type encoder []byte
+
 // Perform sha256.Sum256 against input byte string.
 func hashBytes(input []byte) []byte {
 	sum := sha256.Sum256(input)
@@ -167,9 +212,12 @@ func (e encoder) decode(input []byte, output interface{}) (err error) {
 		return nil
 	}
 
-	if input[0] == 1 {
+	switch input[0] {
+	case crypt_value:
 		i = e.decrypt(input[1:])
-	} else {
+	case partial_value:
+		return e.decodePartial(input, output)
+	default:
 		i = input[1:]
 	}
 
@@ -186,249 +234,250 @@ func (e *encoder) encode(input interface{}) (output []byte, err error) {
 	return buff.Bytes(), err
 }
 
-// Creates a bucket with a common namespace.
-func (K *boltDB) Bucket(name string) Store {
-	return K.Sub(name)
+// Returns encoder of stored values.
+func (K *boltDB) codec() encoder {
+	return K.encoder
 }
 
-// Created a bucket using a different name space.
-func (K *boltDB) Sub(name string) Store {
-	return &substore{fmt.Sprintf("%s%c", name, sepr), K}
+// Returns observer and operation counts.
+func (K *boltDB) stats() *observed {
+	return &K.observed
 }
 
-// Counts keys in table.
-func (K *boltDB) CountKeys(table string) (count int, err error) {
-	err = K.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(table))
-		if bucket == nil {
-			return nil
-		}
-		count = bucket.Stats().KeyN
-		return nil
-	})
-	return
-}
-
-// Lists keys in table.
-func (K *boltDB) Keys(table string) (keys []string, err error) {
-	err = K.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(table))
-		if bucket == nil {
-			return nil
-		}
-		add_key := func(k, v []byte) error {
-			keys = append(keys, string(k))
-			return nil
-		}
-		return bucket.ForEach(add_key)
-	})
-	return keys, err
-}
-
-// Delete a key/value.
-func (K *boltDB) Unset(table, key string) (err error) {
-	return K.db.Update(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(table))
-		if bucket == nil {
-			return nil
+func (K *boltDB) close() (err error) {
+	if K.stop_backups != nil {
+		stop := K.stop_backups
+		K.stop_backups = nil
+		if err = stop(); err != nil {
+			K.close()
+			return err
 		}
-		if err = bucket.Delete([]byte(key)); err != nil {
+	}
+	if K.track_info {
+		K.track_info = false
+		if err = K.closeInfo(); err != nil {
+			K.db.Close()
 			return err
 		}
-		return nil
-	})
-}
-
-// Drops table
-func (K *boltDB) Drop(table string) (err error) {
-	tmp, e := K.buckets(false)
-	if e != nil {
-		return e
 	}
-
-	var tables []string
-	for _, v := range tmp {
-		if strings.HasPrefix(v, fmt.Sprintf("%s%c", table, sepr)) || v == table {
-			tables = append(tables, v)
+	if atomic.LoadInt64(&K.unsynced) > 0 {
+		if err = K.sync(); err != nil {
+			K.db.Close()
+			return err
 		}
 	}
+	return K.db.Close()
+}
 
-	if len(tables) == 0 {
-		return nil
-	}
+// Transaction of bolt database.
+type boltTx struct {
+	*bolt.Tx
+}
 
-	for _, v := range tables {
-		err = K.db.Update(func(tx *bolt.Tx) error {
-			return tx.DeleteBucket([]byte(v))
-		})
+func (tx boltTx) table(name string) txTable {
+	if bucket := tx.Bucket([]byte(name)); bucket != nil {
+		return boltBucket{bucket}
 	}
-	return
+	return nil
 }
 
-// Lists all tables
-func (K *boltDB) Tables() (tables []string, err error) {
-	tmp, e := K.buckets(true)
-	if e != nil {
-		return tables, e
-	}
-	for _, v := range tmp {
-		if !strings.ContainsRune(v, sepr) {
-			tables = append(tables, v)
-		}
+func (tx boltTx) createTable(name string) (txTable, error) {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(name))
+	if err != nil {
+		return nil, err
 	}
-	return tables, err
+	return boltBucket{bucket}, nil
 }
 
-// Returns sub of table.
-func (K *boltDB) Table(table string) Table {
-	return focused{table: table, store: K}
+func (tx boltTx) dropTable(name string) error {
+	if err := tx.DeleteBucket([]byte(name)); err != nil && err != bolt.ErrBucketNotFound {
+		return err
+	}
+	return nil
 }
 
-// Retrieve value from bolt db.
-func (K *boltDB) Get(table, key string, output interface{}) (found bool, err error) {
-	return found, K.db.View(func(tx *bolt.Tx) error {
-		bucket := tx.Bucket([]byte(table))
-		if bucket == nil {
-			found = false
-			return nil
-		}
-		data := bucket.Get([]byte(key))
-		if data != nil {
-			found = true
-			if output == nil {
-				return nil
-			}
-		}
-		return K.encoder.decode(data, output)
+func (tx boltTx) tables() (names []string) {
+	tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		names = append(names, string(name))
+		return nil
 	})
+	return names
 }
 
-func (K *boltDB) Close() (err error) {
-	return K.db.Close()
+// Table of bolt transaction.
+type boltBucket struct {
+	*bolt.Bucket
 }
 
-// Stores encrypted key/value pair.
-func (K *boltDB) CryptSet(table, key string, value interface{}) (err error) {
-	return K.set(table, key, value, true)
+func (b boltBucket) get(key []byte) []byte {
+	return b.Get(key)
 }
 
-// Stores unencrypted key/value pair.
-func (K *boltDB) Set(table, key string, value interface{}) (err error) {
-	return K.set(table, key, value, false)
+func (b boltBucket) put(key, value []byte) error {
+	return b.Put(key, value)
 }
 
-// Stores key/value pair in bolt.
-func (K *boltDB) set(table, key string, value interface{}, encrypt_value bool) (err error) {
-	return K.db.Update(func(tx *bolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists([]byte(table))
-		if err != nil {
-			return err
-		}
-
-		v, err := K.encoder.encode(value)
-		if err != nil {
-			return err
-		}
+func (b boltBucket) delete(key []byte) error {
+	return b.Delete(key)
+}
 
-		if encrypt_value {
-			v = K.encoder.encrypt(v)
-			v = append([]byte{1}, v[0:]...)
-		} else {
-			v = append([]byte{0}, v[0:]...)
-		}
+func (b boltBucket) cursor() txCursor {
+	return b.Cursor()
+}
 
-		return bucket.Put([]byte(key), v)
-	})
+func (b boltBucket) nextSequence() (uint64, error) {
+	return b.NextSequence()
 }
 
 // Resets encryption key on database, removing all encrypted keys in the process.
 func CryptReset(filename string) (err error) {
-	db, err := open(filename)
+	db, err := open(filename, Options{})
 	if err != nil {
 		return err
 	}
 
-	db.Set("KVLite", "Reset", true)
+	setValue(db, "KVLite", "Reset", true, plain_value)
 
-	tables, err := db.buckets(false)
+	err = db.update(func(tx txn) error {
+		for _, name := range tx.tables() {
+			if name == "KVLite" {
+				continue
+			}
+			bucket := tx.table(name)
+
+			var crypted_keys [][]byte
+			plain_keys := make(map[string][]byte)
+
+			c := bucket.cursor()
+			for k, o := c.First(); k != nil; k, o = c.Next() {
+				if len(o) == 0 {
+					continue
+				}
+				switch o[0] {
+				case crypt_value:
+					crypted_keys = append(crypted_keys, append([]byte{}, k...))
+				case partial_value:
+					// Keep plaintext fields of partially encrypted values.
+					if plain, err := stripPartial(o); err == nil {
+						plain_keys[string(k)] = append([]byte{}, plain...)
+						continue
+					}
+					crypted_keys = append(crypted_keys, append([]byte{}, k...))
+				}
+			}
+			for k, v := range plain_keys {
+				if err := bucket.put([]byte(k), v); err != nil {
+					return err
+				}
+			}
+			for _, k := range crypted_keys {
+				if err := bucket.delete(k); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
 	if err != nil {
+		db.close()
 		return err
 	}
 
-	for _, t := range tables {
-		var crypted_keys []string
-		keys, err := db.Keys(t)
-		if err != nil {
+	var info *Info
+	if _, err = getValue(db, "KVLite", "Info", &info); err != nil {
+		db.close()
+		return err
+	}
+
+	err = db.update(func(tx txn) error {
+		if err := tx.dropTable("KVLite"); err != nil {
 			return err
 		}
-		for _, k := range keys {
-			err = db.db.View(func(tx *bolt.Tx) error {
-				bucket := tx.Bucket([]byte(t))
-				if bucket == nil {
-					return nil
-				}
-				o := bucket.Get([]byte(k))
-				if o != nil && o[0] == 1 {
-					crypted_keys = append(crypted_keys, k)
-				}
-				return nil
-			})
-			if err != nil {
-				return err
-			}
+		// Usage statistics are kept across the reset.
+		if info == nil {
+			return nil
 		}
-		for _, k := range crypted_keys {
-			err = db.Unset(t, k)
-			if err != nil {
-				return err
-			}
+		v, err := db.encoder.record(info, plain_value)
+		if err != nil {
+			return err
 		}
-	}
-	err = db.Drop("KVLite")
+		bucket, err := tx.createTable("KVLite")
+		if err != nil {
+			return err
+		}
+		return bucket.put([]byte("Info"), v)
+	})
 	if err != nil {
+		db.close()
 		return err
 	}
-	return db.Close()
+	return db.close()
 }
 
 // Opens bolt keystore.
-func open(filename string) (DB *boltDB, err error) {
-	db, err := bolt.Open(filename, 0600, &bolt.Options{Timeout: 1 * time.Second})
+func open(filename string, opts Options) (DB *boltDB, err error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	db, err := bolt.Open(filename, 0600, &bolt.Options{
+		Timeout:         opts.Timeout,
+		NoGrowSync:      opts.NoGrowSync,
+		InitialMmapSize: opts.InitialMmapSize,
+	})
 	if err != nil {
 		if err == bolt.ErrTimeout {
 			err = ErrLocked
 		}
 		return nil, err
 	}
-	return &boltDB{db: db}, nil
+	DB = &boltDB{db: db}
+	if opts.NoSync || opts.SyncEvery > 0 {
+		db.NoSync = true
+		DB.sync_every = int64(opts.SyncEvery)
+	}
+	return DB, nil
 }
 
 // Opens BoltDB backed kvlite.Store.
 func Open(filename string, padlock ...byte) (Store, error) {
-	db, err := open(filename)
+	return OpenWithOptions(filename, Options{}, padlock...)
+}
+
+// Opens BoltDB backed kvlite.Store, tuned by opts.
+func OpenWithOptions(filename string, opts Options, padlock ...byte) (Store, error) {
+	db, err := openStore(filename, opts, padlock)
+	if err != nil {
+		return nil, err
+	}
+	return rootStore(db), nil
+}
+
+// Opens bolt keystore, resetting it if requested and unlocking it with padlock.
+func openStore(filename string, opts Options, padlock []byte) (*boltDB, error) {
+	db, err := open(filename, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	found, err := db.Get("KVLite", "Reset", nil)
+	found, err := getValue(db, "KVLite", "Reset", nil)
 	if err != nil {
 		return nil, err
 	}
 
 	if found {
-		db.Close()
+		db.close()
 		err = CryptReset(filename)
 		if err != nil {
 			return nil, err
 		}
-		db, err = open(filename)
+		db, err = open(filename, opts)
 		if err != nil {
 			return nil, err
 		}
 	}
 
 	var X *xLock
-	_, err = db.Get("KVLite", "X", &X)
+	_, err = getValue(db, "KVLite", "X", &X)
 	if err != nil {
 		return nil, err
 	}
@@ -438,9 +487,14 @@ func Open(filename string, padlock ...byte) (Store, error) {
 
 	db.encoder, err = X.dbunlocker(padlock)
 	if err != nil {
-		db.Close()
+		db.close()
+		return nil, err
+	}
+
+	if err = db.openInfo(); err != nil {
+		db.close()
 		return nil, err
 	}
 	//err = db.Set("KVLite", "X", &X)
-	return db, err
+	return db, nil
 }
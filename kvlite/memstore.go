@@ -1,166 +1,221 @@
 package kvlite
 
 import (
-	"fmt"
-	"strings"
+	"github.com/boltdb/bolt"
+	"sort"
 	"sync"
+	"time"
 )
 
 // Memory-Map keystore
 type memStore struct {
 	mutex   sync.RWMutex
 	kv      map[string]map[string][]byte
+	seq     map[string]uint64
 	encoder encoder
+	created time.Time
+	observed
 }
 
-// Returns sub of table.
-func (K *memStore) Table(table string) Table {
-	return focused{table: table, store: K}
-}
-
-// Use a toplevel namespace.
-func (K *memStore) Bucket(name string) Store {
-	return K.Sub(name)
-}
-
-// Changes bucket name.
-func (K *memStore) Sub(table string) Store {
-	return &substore{fmt.Sprintf("%s%c", table, sepr), K}
-}
-
-func (K *memStore) buckets(limit_depth bool) (buckets []string, err error) {
+// Runs fn holding a read lock on the store.
+func (K *memStore) view(fn func(tx txn) error) error {
 	K.mutex.RLock()
 	defer K.mutex.RUnlock()
+	return fn(&memTx{store: K})
+}
 
-	bmap := make(map[string]struct{})
+// Runs fn holding the store locked, changes are undone should fn return an error.
+func (K *memStore) update(fn func(tx txn) error) (err error) {
+	K.mutex.Lock()
+	defer K.mutex.Unlock()
 
-	for k := range K.kv {
-		if !limit_depth {
-			buckets = append(buckets, k)
-		} else {
-			k = strings.Split(k, string(sepr))[0]
-			if _, ok := bmap[k]; !ok {
-				bmap[k] = struct{}{}
-				buckets = append(buckets, k)
-			}
+	tx := &memTx{store: K, writable: true}
+	if err = fn(tx); err != nil {
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
 		}
 	}
-	return
+	return err
 }
 
-func (K *memStore) Keys(table string) (keys []string, err error) {
-	K.mutex.RLock()
-	defer K.mutex.RUnlock()
-	if t, ok := K.kv[table]; ok {
-		for k := range t {
-			keys = append(keys, k)
-		}
-	}
-	return keys, nil
+// Returns encoder of stored values.
+func (K *memStore) codec() encoder {
+	return K.encoder
 }
 
-func (K *memStore) Tables() (tables []string, err error) {
-	tmp, e := K.buckets(true)
-	if err != nil {
-		return tables, e
-	}
-	for _, v := range tmp {
-		if !strings.ContainsRune(v, sepr) {
-			tables = append(tables, v)
-		}
-	}
-	return tables, err
+// Returns observer and operation counts.
+func (K *memStore) stats() *observed {
+	return &K.observed
 }
 
-func (K *memStore) Drop(table string) (err error) {
+// Closed MemStore
+func (K *memStore) close() (err error) {
 	K.mutex.Lock()
 	defer K.mutex.Unlock()
-
 	for k := range K.kv {
-		if strings.HasPrefix(k, fmt.Sprintf("%s%c", table, sepr)) || k == table {
-			delete(K.kv, k)
-		}
+		delete(K.kv, k)
+	}
+	for k := range K.seq {
+		delete(K.seq, k)
 	}
 	return nil
 }
 
-func (K *memStore) Unset(table, key string) (err error) {
-	K.mutex.Lock()
-	defer K.mutex.Unlock()
-	if t, ok := K.kv[table]; ok {
-		delete(t, key)
+// Transaction of memory store, recording how to undo each change.
+type memTx struct {
+	store    *memStore
+	writable bool
+	undo     []func()
+}
+
+func (tx *memTx) table(name string) txTable {
+	if t, ok := tx.store.kv[name]; ok {
+		return &memTable{tx, name, t}
 	}
 	return nil
 }
 
-func (K *memStore) Get(table, key string, output interface{}) (found bool, err error) {
-	K.mutex.RLock()
-	defer K.mutex.RUnlock()
-	if t, ok := K.kv[table]; ok {
-		if v, ok := t[key]; ok {
-			return true, K.encoder.decode(v, output)
-		}
+func (tx *memTx) createTable(name string) (txTable, error) {
+	if t := tx.table(name); t != nil {
+		return t, nil
 	}
-	return false, nil
+	if !tx.writable {
+		return nil, bolt.ErrTxNotWritable
+	}
+	tx.store.kv[name] = make(map[string][]byte)
+	tx.undo = append(tx.undo, func() { delete(tx.store.kv, name) })
+	return tx.table(name), nil
 }
 
-// Returns list of keys in table in memory store.
-func (K *memStore) CountKeys(table string) (count int, err error) {
-	K.mutex.RLock()
-	defer K.mutex.RUnlock()
-	if t, ok := K.kv[table]; ok {
-		count = len(t)
+func (tx *memTx) dropTable(name string) error {
+	if !tx.writable {
+		return bolt.ErrTxNotWritable
+	}
+	t, ok := tx.store.kv[name]
+	if !ok {
+		return nil
 	}
-	return count, nil
+	seq, has_seq := tx.store.seq[name]
+	delete(tx.store.kv, name)
+	delete(tx.store.seq, name)
+	tx.undo = append(tx.undo, func() {
+		tx.store.kv[name] = t
+		if has_seq {
+			tx.store.seq[name] = seq
+		}
+	})
+	return nil
 }
 
-// Set key/value in memory store.
-func (K *memStore) Set(table, key string, value interface{}) (err error) {
-	return K.set(table, key, value, false)
+func (tx *memTx) tables() (names []string) {
+	for k := range tx.store.kv {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
 }
 
-// Encrypt key/value in memory store.
-func (K *memStore) CryptSet(table, key string, value interface{}) (err error) {
-	return K.set(table, key, value, true)
+// Table of memory store transaction.
+type memTable struct {
+	tx   *memTx
+	name string
+	kv   map[string][]byte
 }
 
-func (K *memStore) set(table, key string, value interface{}, encrypt_value bool) (err error) {
-	K.mutex.Lock()
-	defer K.mutex.Unlock()
+func (t *memTable) get(key []byte) []byte {
+	return t.kv[string(key)]
+}
 
-	if _, ok := K.kv[table]; !ok {
-		K.kv[table] = make(map[string][]byte)
+func (t *memTable) put(key, value []byte) error {
+	if !t.tx.writable {
+		return bolt.ErrTxNotWritable
 	}
+	k := string(key)
+	old, found := t.kv[k]
+	t.kv[k] = append([]byte{}, value...)
+	t.tx.undo = append(t.tx.undo, func() {
+		if found {
+			t.kv[k] = old
+		} else {
+			delete(t.kv, k)
+		}
+	})
+	return nil
+}
 
-	v, err := K.encoder.encode(value)
-	if err != nil {
-		return err
+func (t *memTable) delete(key []byte) error {
+	if !t.tx.writable {
+		return bolt.ErrTxNotWritable
 	}
+	k := string(key)
+	old, found := t.kv[k]
+	if !found {
+		return nil
+	}
+	delete(t.kv, k)
+	t.tx.undo = append(t.tx.undo, func() { t.kv[k] = old })
+	return nil
+}
 
-	if encrypt_value {
-		v = K.encoder.encrypt(v)
-		v = append([]byte{1}, v[0:]...)
-	} else {
-		v = append([]byte{0}, v[0:]...)
+// Returns cursor over keys of table as they were when the cursor was created.
+func (t *memTable) cursor() txCursor {
+	keys := make([]string, 0, len(t.kv))
+	for k := range t.kv {
+		keys = append(keys, k)
 	}
+	sort.Strings(keys)
+	return &memCursor{table: t, keys: keys}
+}
 
-	K.kv[table][key] = v
+func (t *memTable) nextSequence() (uint64, error) {
+	if !t.tx.writable {
+		return 0, bolt.ErrTxNotWritable
+	}
+	seq := t.tx.store.seq[t.name]
+	t.tx.store.seq[t.name] = seq + 1
+	t.tx.undo = append(t.tx.undo, func() { t.tx.store.seq[t.name] = seq })
+	return seq + 1, nil
+}
 
-	return nil
+// Cursor over sorted keys of memory table.
+type memCursor struct {
+	table *memTable
+	keys  []string
+	pos   int
+}
 
+// Returns key and value at position of cursor.
+func (c *memCursor) current() (key, value []byte) {
+	if c.pos >= len(c.keys) {
+		return nil, nil
+	}
+	k := c.keys[c.pos]
+	return []byte(k), c.table.kv[k]
 }
 
-// Closed MemStore
-func (K *memStore) Close() (err error) {
-	K.mutex.Lock()
-	defer K.mutex.Unlock()
-	for k := range K.kv {
-		delete(K.kv, k)
+func (c *memCursor) First() (key, value []byte) {
+	c.pos = 0
+	return c.current()
+}
+
+func (c *memCursor) Seek(seek []byte) (key, value []byte) {
+	c.pos = sort.SearchStrings(c.keys, string(seek))
+	return c.current()
+}
+
+func (c *memCursor) Next() (key, value []byte) {
+	if c.pos < len(c.keys) {
+		c.pos++
 	}
-	return nil
+	return c.current()
 }
 
 // Creates a new ephemeral memory based kvliter.Store.
 func MemStore() Store {
-	return &memStore{kv: make(map[string]map[string][]byte), encoder: hashBytes(randBytes(256))}
+	return rootStore(&memStore{
+		kv:      make(map[string]map[string][]byte),
+		seq:     make(map[string]uint64),
+		encoder: hashBytes(randBytes(256)),
+		created: time.Now(),
+	})
 }
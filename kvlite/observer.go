@@ -0,0 +1,53 @@
+package kvlite
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Observer receives the operation name, table, duration and result of each store operation.
+type Observer func(op, table string, dur time.Duration, err error)
+
+// Holds the observer and operation counts shared by all namespaces of a database.
+type observed struct {
+	fn atomic.Value
+	counters
+}
+
+// Sets function called after each operation on the database, nil removes the observer.
+func (o *observed) SetObserver(fn Observer) {
+	o.fn.Store(fn)
+}
+
+// Returns current observer, if any.
+func (o *observed) observer() Observer {
+	fn, _ := o.fn.Load().(Observer)
+	return fn
+}
+
+// Returns readable table name, with namespaces separated by '/'.
+func (d substore) name(table string) string {
+	if d.prefix == "" {
+		return table
+	}
+	names := strings.Split(strings.TrimSuffix(d.prefix, string(sepr)), string(sepr))
+	for i := range names {
+		names[i] = unescapeName(names[i])
+	}
+	if table != "" {
+		names = append(names, table)
+	}
+	return strings.Join(names, "/")
+}
+
+// Counts completed operation and reports it to observer, then wraps any error with the operation, table and key.
+func (d substore) track(op, table, key string, start time.Time, err *error) {
+	d.db.stats().add(op)
+	if fn := d.db.stats().observer(); fn != nil {
+		fn(op, d.name(table), time.Since(start), *err)
+	}
+	if *err != nil {
+		*err = &StoreError{Op: op, Table: d.name(table), Key: key, Err: *err}
+	}
+}
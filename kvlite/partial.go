@@ -0,0 +1,144 @@
+package kvlite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// Markers leading each stored value.
+const (
+	plain_value   = 0
+	crypt_value   = 1
+	partial_value = 2
+)
+
+// Reports whether struct field is tagged `kvlite:"encrypt"`.
+func encryptField(field reflect.StructField) bool {
+	for _, opt := range strings.Split(field.Tag.Get("kvlite"), ",") {
+		if opt == "encrypt" {
+			return true
+		}
+	}
+	return false
+}
+
+// Encodes value to a stored value, marked with how it was encrypted.
+func (e encoder) record(value interface{}, marker byte) (output []byte, err error) {
+	if marker == partial_value {
+		return e.encodePartial(value)
+	}
+	v, err := e.encode(value)
+	if err != nil {
+		return nil, err
+	}
+	if marker == crypt_value {
+		v = e.encrypt(v)
+	}
+	return append([]byte{marker}, v...), nil
+}
+
+// Encodes struct with fields tagged `kvlite:"encrypt"` encrypted, and the remaining fields in plaintext.
+// Values other than structs are encrypted entirely.
+func (e encoder) encodePartial(value interface{}) (output []byte, err error) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return e.record(value, crypt_value)
+	}
+
+	plain := reflect.New(v.Type()).Elem()
+	plain.Set(v)
+
+	secrets := make(map[string][]byte)
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !encryptField(field) || field.PkgPath != "" || v.Field(i).IsZero() {
+			continue
+		}
+		secret, err := e.encode(v.Field(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		secrets[field.Name] = secret
+		plain.Field(i).Set(reflect.Zero(field.Type))
+	}
+
+	plain_bytes, err := e.encode(plain.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	secret_bytes, err := e.encode(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	output = append([]byte{partial_value}, binary.AppendUvarint(nil, uint64(len(plain_bytes)))...)
+	output = append(output, plain_bytes...)
+	return append(output, e.encrypt(secret_bytes)...), nil
+}
+
+var errPartialValue = errors.New("Stored value is malformed.")
+
+// Splits partially encrypted value in to its plaintext and encrypted parts.
+func splitPartial(input []byte) (plain, secret []byte, err error) {
+	size, n := binary.Uvarint(input[1:])
+	if n <= 0 || uint64(len(input)-1-n) < size {
+		return nil, nil, errPartialValue
+	}
+	plain = input[1+n : 1+n+int(size)]
+	return plain, input[1+n+int(size):], nil
+}
+
+// Removes encrypted fields from partially encrypted value, leaving the plaintext fields.
+func stripPartial(input []byte) ([]byte, error) {
+	_, secret, err := splitPartial(input)
+	if err != nil {
+		return nil, err
+	}
+	return input[0 : len(input)-len(secret)], nil
+}
+
+// Decodes partially encrypted value in to output, restoring encrypted fields.
+func (e encoder) decodePartial(input []byte, output interface{}) (err error) {
+	plain, secret, err := splitPartial(input)
+	if err != nil {
+		return err
+	}
+
+	if err = gob.NewDecoder(bytes.NewReader(plain)).Decode(output); err != nil || len(secret) == 0 {
+		return err
+	}
+
+	secrets := make(map[string][]byte)
+	if err = gob.NewDecoder(bytes.NewReader(e.decrypt(secret))).Decode(&secrets); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(output)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for name, secret := range secrets {
+		field := v.FieldByName(name)
+		if !field.IsValid() || !field.CanAddr() {
+			continue
+		}
+		if err = gob.NewDecoder(bytes.NewReader(secret)).Decode(field.Addr().Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
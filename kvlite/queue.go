@@ -0,0 +1,282 @@
+package kvlite
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrReceipt is returned by Ack and Requeue when the item was acknowledged, or popped again, since the receipt was issued.
+var ErrReceipt = errors.New("Receipt is no longer valid for queue item.")
+
+// Default time a popped item remains hidden before being made available again.
+const DefaultVisibilityTimeout = time.Minute
+
+// Queue provides FIFO ordering of values persisted in a Store.
+// Popped items remain hidden until acknowledged, requeued or the visibility timeout expires.
+// Each operation runs in a single transaction, so a queue may be shared by goroutines and by processes sharing the database.
+type Queue struct {
+	ns      *substore
+	mutex   sync.RWMutex
+	timeout time.Duration
+}
+
+// Item as stored in the queue table.
+type queueItem struct {
+	Value    []byte
+	Visible  time.Time
+	Attempts int
+}
+
+// Queue returns persistent queue name, kept within its own namespace of the store.
+func (d *substore) Queue(name string) *Queue {
+	return &Queue{ns: newSub(d.prefix, name, d.db), timeout: DefaultVisibilityTimeout}
+}
+
+// Sets the time a popped item stays hidden before being made available again.
+func (q *Queue) SetVisibilityTimeout(timeout time.Duration) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.timeout = timeout
+}
+
+// Returns visibility timeout of queue.
+func (q *Queue) visibility() time.Duration {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+	return q.timeout
+}
+
+// Returns key of item with sequence number seq.
+func queueKey(seq uint64) []byte {
+	return []byte(fmt.Sprintf("%020d", seq))
+}
+
+// Returns receipt of item key popped for the given attempt.
+func queueReceipt(key []byte, attempts int) string {
+	return fmt.Sprintf("%s.%d", key, attempts)
+}
+
+// Returns item key and attempt of receipt.
+func parseReceipt(receipt string) (key []byte, attempts int, err error) {
+	i := strings.LastIndexByte(receipt, '.')
+	if i < 0 {
+		return nil, 0, ErrReceipt
+	}
+	if attempts, err = strconv.Atoi(receipt[i+1:]); err != nil {
+		return nil, 0, ErrReceipt
+	}
+	return []byte(receipt[:i]), attempts, nil
+}
+
+// Runs fn on the items table of queue within a read-write transaction.
+func (q *Queue) update(fn func(items txTable) error) error {
+	table, err := q.ns.apply_prefix("items")
+	if err != nil {
+		return err
+	}
+	return q.ns.db.update(func(tx txn) error {
+		items, err := tx.createTable(table)
+		if err != nil {
+			return err
+		}
+		return fn(items)
+	})
+}
+
+// Runs fn on the items table of queue within a read-only transaction, fn is not called if the queue is empty.
+func (q *Queue) view(fn func(items txTable) error) error {
+	table, err := q.ns.apply_prefix("items")
+	if err != nil {
+		return err
+	}
+	return q.ns.db.view(func(tx txn) error {
+		if items := tx.table(table); items != nil {
+			return fn(items)
+		}
+		return nil
+	})
+}
+
+// Finds the first visible item of items.
+func (q *Queue) next(items txTable, now time.Time) (key []byte, item *queueItem, err error) {
+	c := items.cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var i queueItem
+		if err = q.ns.db.codec().decode(v, &i); err != nil {
+			return nil, nil, err
+		}
+		if !i.Visible.After(now) {
+			return append([]byte{}, k...), &i, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// Adds value to the end of the queue.
+func (q *Queue) Push(value interface{}) (err error) {
+	defer q.ns.track("Push", "items", "", time.Now(), &err)
+
+	v, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+	record, err := q.ns.db.codec().record(&queueItem{Value: v}, plain_value)
+	if err != nil {
+		return err
+	}
+
+	return q.update(func(items txTable) error {
+		seq, err := items.nextSequence()
+		if err != nil {
+			return err
+		}
+		return items.put(queueKey(seq), record)
+	})
+}
+
+// Retrieves the first visible item in to output, hiding it until acknowledged with Ack.
+// The receipt returned identifies this pop of the item, and is refused by Ack and Requeue once the item is popped again.
+func (q *Queue) Pop(output interface{}) (receipt string, found bool, err error) {
+	defer q.ns.track("Pop", "items", "", time.Now(), &err)
+
+	var value []byte
+	timeout := q.visibility()
+
+	err = q.update(func(items txTable) error {
+		now := time.Now()
+		key, item, err := q.next(items, now)
+		if err != nil || item == nil {
+			return err
+		}
+
+		item.Visible = now.Add(timeout)
+		item.Attempts++
+
+		record, err := q.ns.db.codec().record(item, plain_value)
+		if err != nil {
+			return err
+		}
+		if err = items.put(key, record); err != nil {
+			return err
+		}
+
+		receipt, found, value = queueReceipt(key, item.Attempts), true, item.Value
+		return nil
+	})
+	if err != nil || !found {
+		return "", false, err
+	}
+	return receipt, true, gob.NewDecoder(bytes.NewReader(value)).Decode(output)
+}
+
+// Retrieves the first visible item in to output, without removing or hiding it.
+func (q *Queue) Peek(output interface{}) (found bool, err error) {
+	defer q.ns.track("Peek", "items", "", time.Now(), &err)
+
+	var value []byte
+
+	err = q.view(func(items txTable) error {
+		_, item, err := q.next(items, time.Now())
+		if err != nil || item == nil {
+			return err
+		}
+		found, value = true, item.Value
+		return nil
+	})
+	if err != nil || !found {
+		return false, err
+	}
+	return true, gob.NewDecoder(bytes.NewReader(value)).Decode(output)
+}
+
+// Retrieves item popped with receipt, ErrReceipt is returned if it has since been removed or popped again.
+func (q *Queue) popped(items txTable, receipt string) (key []byte, item *queueItem, err error) {
+	key, attempts, err := parseReceipt(receipt)
+	if err != nil {
+		return nil, nil, err
+	}
+	v := items.get(key)
+	if v == nil {
+		return nil, nil, ErrReceipt
+	}
+	item = new(queueItem)
+	if err = q.ns.db.codec().decode(v, item); err != nil {
+		return nil, nil, err
+	}
+	if item.Attempts != attempts {
+		return nil, nil, ErrReceipt
+	}
+	return key, item, nil
+}
+
+// Removes item popped with receipt from the queue.
+func (q *Queue) Ack(receipt string) (err error) {
+	defer q.ns.track("Ack", "items", receipt, time.Now(), &err)
+	return q.update(func(items txTable) error {
+		key, _, err := q.popped(items, receipt)
+		if err != nil {
+			return err
+		}
+		return items.delete(key)
+	})
+}
+
+// Makes item popped with receipt visible again, retaining its place in the queue.
+func (q *Queue) Requeue(receipt string) (err error) {
+	defer q.ns.track("Requeue", "items", receipt, time.Now(), &err)
+	return q.update(func(items txTable) error {
+		key, item, err := q.popped(items, receipt)
+		if err != nil {
+			return err
+		}
+		item.Visible = time.Time{}
+		record, err := q.ns.db.codec().record(item, plain_value)
+		if err != nil {
+			return err
+		}
+		return items.put(key, record)
+	})
+}
+
+// Returns the number of times item popped with receipt has been popped, ErrReceipt is returned as by Ack and Requeue.
+func (q *Queue) Attempts(receipt string) (attempts int, err error) {
+	defer q.ns.track("Attempts", "items", receipt, time.Now(), &err)
+
+	var found bool
+	err = q.view(func(items txTable) error {
+		_, item, err := q.popped(items, receipt)
+		if err != nil {
+			return err
+		}
+		attempts, found = item.Attempts, true
+		return nil
+	})
+	// An empty queue holds no popped items.
+	if err == nil && !found {
+		return 0, ErrReceipt
+	}
+	return attempts, err
+}
+
+// Returns the number of items in the queue, including those hidden.
+func (q *Queue) Len() (count int, err error) {
+	defer q.ns.track("Len", "items", "", time.Now(), &err)
+	err = q.view(func(items txTable) error {
+		count = countKeys(items)
+		return nil
+	})
+	return count, err
+}
+
+// Gob encodes value of queue item.
+func encodeValue(value interface{}) ([]byte, error) {
+	var buff bytes.Buffer
+	err := gob.NewEncoder(&buff).Encode(value)
+	return buff.Bytes(), err
+}
@@ -0,0 +1,39 @@
+package kvlite
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQueueAttempts(t *testing.T) {
+	for backend, db := range testStores(t) {
+		q := db.Queue("jobs")
+
+		if _, err := q.Attempts(queueReceipt(queueKey(1), 1)); !errors.Is(err, ErrReceipt) {
+			t.Errorf("%s: Attempts on empty queue returned %v, want ErrReceipt", backend, err)
+		}
+
+		if err := q.Push("job"); err != nil {
+			t.Fatal(err)
+		}
+		var value string
+		receipt, found, err := q.Pop(&value)
+		if err != nil || !found {
+			t.Fatalf("%s: Pop returned %v, %v", backend, found, err)
+		}
+		if attempts, err := q.Attempts(receipt); err != nil || attempts != 1 {
+			t.Errorf("%s: Attempts returned %d, %v, want 1", backend, attempts, err)
+		}
+
+		// Once acknowledged, the receipt is refused as Ack refuses it.
+		if err := q.Ack(receipt); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := q.Attempts(receipt); !errors.Is(err, ErrReceipt) {
+			t.Errorf("%s: Attempts after Ack returned %v, want ErrReceipt", backend, err)
+		}
+		if err := q.Ack(receipt); !errors.Is(err, ErrReceipt) {
+			t.Errorf("%s: second Ack returned %v, want ErrReceipt", backend, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package kvlite
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"time"
+)
+
+// Replicate mirrors tables of src to dst every interval until ctx is done, if no tables are given all tables are mirrored, including those of nested namespaces.
+// Each pass compares the tables of both stores, writing keys added or changed in src to dst and removing keys src no longer has.
+// When mirroring all tables, tables src no longer has are dropped from dst.
+// Encrypted values are decrypted with the key of src and encrypted again with the key of dst, as with Copy.
+// Replicate returns ctx.Err() once ctx is done, or the error of a failed pass, an interval of 0 or less mirrors once and returns.
+func Replicate(ctx context.Context, src, dst Store, interval time.Duration, tables ...string) (err error) {
+	var names []string
+
+	for _, t := range tables {
+		if t == "" {
+			return ErrEmptyName
+		}
+		names = append(names, escapeName(t))
+	}
+
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	if interval <= 0 {
+		return replicate(src.namespace(), dst.namespace(), names)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = replicate(src.namespace(), dst.namespace(), names); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Mirrors tables of src to dst, all tables of src when names is empty, dropping tables of dst src does not have.
+func replicate(src, dst *substore, names []string) (err error) {
+	if len(names) == 0 {
+		if names, err = src.buckets(false); err != nil {
+			return err
+		}
+		keep := make(map[string]struct{})
+		for _, name := range names {
+			keep[dst.prefix+name] = struct{}{}
+		}
+		err = dst.db.update(func(tx txn) error {
+			return dropTables(tx, func(name string) bool {
+				if name == "KVLite" || !strings.HasPrefix(name, dst.prefix) {
+					return false
+				}
+				// Leave src be, should it be nested within dst.
+				if src.db == dst.db && strings.HasPrefix(name, src.prefix) {
+					return false
+				}
+				_, ok := keep[name]
+				return !ok
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		records, err := src.export(name)
+		if err != nil {
+			return err
+		}
+		current, err := dst.export(name)
+		if err != nil {
+			return err
+		}
+
+		changes := make(map[string][]byte)
+		for k, v := range records {
+			if c, ok := current[k]; !ok || !bytes.Equal(c, v) {
+				changes[k] = v
+			}
+		}
+		for k := range current {
+			if _, ok := records[k]; !ok {
+				changes[k] = nil
+			}
+		}
+
+		if len(changes) == 0 {
+			continue
+		}
+		if err = dst.restore(name, changes); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
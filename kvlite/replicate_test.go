@@ -0,0 +1,53 @@
+package kvlite
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestReplicateDropsTables(t *testing.T) {
+	for backend, dst := range testStores(t) {
+		src := MemStore()
+		if err := src.Set("users", "a", "1"); err != nil {
+			t.Fatal(err)
+		}
+		if err := src.Set("old", "b", "2"); err != nil {
+			t.Fatal(err)
+		}
+		if err := Replicate(context.Background(), src, dst, 0); err != nil {
+			t.Fatal(err)
+		}
+		if tables := sortedTables(t, dst); !reflect.DeepEqual(tables, []string{"old", "users"}) {
+			t.Fatalf("%s: tables after first pass = %v", backend, tables)
+		}
+
+		if err := src.Drop("old"); err != nil {
+			t.Fatal(err)
+		}
+		if err := Replicate(context.Background(), src, dst, 0); err != nil {
+			t.Fatal(err)
+		}
+		if tables := sortedTables(t, dst); !reflect.DeepEqual(tables, []string{"users"}) {
+			t.Errorf("%s: tables after drop = %v, want [users]", backend, tables)
+		}
+	}
+}
+
+func TestReplicateCancelled(t *testing.T) {
+	src, dst := MemStore(), MemStore()
+	if err := src.Set("users", "a", "1"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := Replicate(ctx, src, dst, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("Replicate returned %v, want context.Canceled", err)
+	}
+	if tables := sortedTables(t, dst); len(tables) != 0 {
+		t.Errorf("tables copied after cancel: %v", tables)
+	}
+}
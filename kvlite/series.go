@@ -0,0 +1,206 @@
+package kvlite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+// Series stores time-ordered numeric samples in a Store.
+type Series struct {
+	ns        *substore
+	mutex     sync.RWMutex
+	retention time.Duration
+}
+
+// Sample is a single value recorded in a Series.
+type Sample struct {
+	Time  time.Time
+	Value float64
+}
+
+// NewSeries returns time-ordered series name, kept within its own namespace of db.
+func NewSeries(db Store, name string) *Series {
+	d := db.namespace()
+	return &Series{ns: newSub(d.prefix, name, d.db)}
+}
+
+// Returns time ordered key for t, big-endian with the sign bit flipped so times before 1970 sort first.
+func seriesKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano())^1<<63)
+	return key
+}
+
+// Returns time of series key.
+func seriesTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key)^1<<63))
+}
+
+// Sets how long samples are kept, older samples are pruned as new samples are added.
+func (s *Series) SetRetention(retention time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.retention = retention
+}
+
+// Runs fn on the samples table of series within a read-write transaction.
+func (s *Series) update(fn func(samples txTable) error) error {
+	table, err := s.ns.apply_prefix("samples")
+	if err != nil {
+		return err
+	}
+	return s.ns.db.update(func(tx txn) error {
+		samples, err := tx.createTable(table)
+		if err != nil {
+			return err
+		}
+		return fn(samples)
+	})
+}
+
+// Runs fn on the samples table of series within a read-only transaction, fn is not called if the series is empty.
+func (s *Series) view(fn func(samples txTable) error) error {
+	table, err := s.ns.apply_prefix("samples")
+	if err != nil {
+		return err
+	}
+	return s.ns.db.view(func(tx txn) error {
+		if samples := tx.table(table); samples != nil {
+			return fn(samples)
+		}
+		return nil
+	})
+}
+
+// Records value at time t, a sample already at t is replaced.
+func (s *Series) Add(t time.Time, value float64) (err error) {
+	defer s.ns.track("Add", "samples", "", time.Now(), &err)
+
+	s.mutex.RLock()
+	retention := s.retention
+	s.mutex.RUnlock()
+
+	v, err := s.ns.db.codec().record(value, plain_value)
+	if err != nil {
+		return err
+	}
+
+	return s.update(func(samples txTable) error {
+		if err := samples.put(seriesKey(t), v); err != nil {
+			return err
+		}
+		if retention > 0 {
+			return prune(samples, time.Now().Add(-retention))
+		}
+		return nil
+	})
+}
+
+// Returns samples at or after from and before to, in time order.
+func (s *Series) Range(from, to time.Time) (samples []Sample, err error) {
+	defer s.ns.track("Range", "samples", "", time.Now(), &err)
+	err = s.view(func(table txTable) (err error) {
+		samples, err = s.samples(table, seriesKey(from), seriesKey(to))
+		return err
+	})
+	return samples, err
+}
+
+// Returns samples of table with keys from start up to end, in time order.
+func (s *Series) samples(table txTable, start, end []byte) (samples []Sample, err error) {
+	c := table.cursor()
+	for k, v := c.Seek(start); k != nil && bytes.Compare(k, end) < 0; k, v = c.Next() {
+		var value float64
+		if err = s.ns.db.codec().decode(v, &value); err != nil {
+			return nil, err
+		}
+		samples = append(samples, Sample{seriesTime(k), value})
+	}
+	return samples, nil
+}
+
+// Removes all samples before cutoff.
+func (s *Series) Prune(cutoff time.Time) (err error) {
+	defer s.ns.track("Prune", "samples", "", time.Now(), &err)
+	return s.update(func(samples txTable) error {
+		return prune(samples, cutoff)
+	})
+}
+
+// Removes samples of table before cutoff, seeking no further than cutoff.
+func prune(samples txTable, cutoff time.Time) (err error) {
+	end := seriesKey(cutoff)
+
+	var keys [][]byte
+	c := samples.cursor()
+	for k, _ := c.First(); k != nil && bytes.Compare(k, end) < 0; k, _ = c.Next() {
+		keys = append(keys, append([]byte{}, k...))
+	}
+
+	for _, k := range keys {
+		if err = samples.delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replaces samples before cutoff with their average over each interval.
+func (s *Series) Downsample(cutoff time.Time, interval time.Duration) (err error) {
+	defer s.ns.track("Downsample", "samples", "", time.Now(), &err)
+
+	if interval <= 0 {
+		return nil
+	}
+
+	return s.update(func(table txTable) error {
+		samples, err := s.samples(table, nil, seriesKey(cutoff))
+		if err != nil {
+			return err
+		}
+
+		for len(samples) > 0 {
+			start := samples[0].Time.Truncate(interval)
+
+			var (
+				n   int
+				sum float64
+			)
+
+			for n < len(samples) && samples[n].Time.Truncate(interval).Equal(start) {
+				sum += samples[n].Value
+				n++
+			}
+
+			if n > 1 || !samples[0].Time.Equal(start) {
+				for _, v := range samples[:n] {
+					if err = table.delete(seriesKey(v.Time)); err != nil {
+						return err
+					}
+				}
+				v, err := s.ns.db.codec().record(sum/float64(n), plain_value)
+				if err != nil {
+					return err
+				}
+				if err = table.put(seriesKey(start), v); err != nil {
+					return err
+				}
+			}
+
+			samples = samples[n:]
+		}
+		return nil
+	})
+}
+
+// Returns the number of samples in the series.
+func (s *Series) Len() (count int, err error) {
+	defer s.ns.track("Len", "samples", "", time.Now(), &err)
+	err = s.view(func(samples txTable) error {
+		count = countKeys(samples)
+		return nil
+	})
+	return count, err
+}
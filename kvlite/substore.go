@@ -1,109 +1,385 @@
 package kvlite
 
 import (
-	"fmt"
+	"errors"
 	"strings"
+	"time"
 )
 
+// Namespace of a database, implementing Store over its backend.
 type substore struct {
 	prefix string
-	db     Store
+	db     backend
 }
 
-const sepr = '\x1f'
+const (
+	sepr = '\x1f'
+	esc  = '\x1e'
+)
+
+var (
+	// ErrEmptyName is returned when an empty table name is supplied.
+	ErrEmptyName = errors.New("Table name cannot be empty.")
+	// ErrReservedName is returned when a table name conflicts with the internal KVLite namespace.
+	ErrReservedName = errors.New("Table name \"KVLite\" is reserved for internal use.")
+)
+
+var (
+	name_escaper   = strings.NewReplacer(string(esc), string(esc)+"0", string(sepr), string(esc)+"1")
+	name_unescaper = strings.NewReplacer(string(esc)+"0", string(esc), string(esc)+"1", string(sepr))
+)
+
+// Escapes separator in user-supplied name, so it cannot break out of its namespace.
+func escapeName(name string) string {
+	return name_escaper.Replace(name)
+}
+
+// Restores user-supplied name from its escaped form.
+func unescapeName(name string) string {
+	return name_unescaper.Replace(name)
+}
+
+// Wraps backend in a root namespace which validates table names.
+func rootStore(db backend) Store {
+	return &substore{"", db}
+}
+
+// Creates a namespace under prefix, an empty name returns the prefix itself.
+func newSub(prefix, name string, db backend) *substore {
+	if name == "" {
+		return &substore{prefix, db}
+	}
+	return &substore{prefix + escapeName(name) + string(sepr), db}
+}
 
 // applies prefix of table to calls.
-func (d substore) apply_prefix(name string) string {
-	return string(append([]rune(d.prefix), []rune(name)...))
+func (d substore) apply_prefix(name string) (string, error) {
+	if name == "" {
+		return name, ErrEmptyName
+	}
+	if d.prefix == "" && name == "KVLite" {
+		return name, ErrReservedName
+	}
+	return d.prefix + escapeName(name), nil
+}
+
+func (d *substore) namespace() *substore {
+	return d
 }
 
 func (d *substore) Sub(name string) Store {
-	return &substore{fmt.Sprintf("%s%s%c", d.prefix, name, sepr), d.db}
+	return newSub(d.prefix, name, d.db)
 }
 
 // Creates a bucket with a common namespace.
 func (d *substore) Bucket(name string) Store {
-	return d.db.Sub(name)
+	return newSub("", name, d.db)
+}
+
+// Sets function called after each operation on the database, shared by all namespaces.
+func (d substore) SetObserver(fn Observer) {
+	d.db.stats().SetObserver(fn)
+}
+
+// Returns usage statistics of underlying database.
+func (d substore) Info() (info Info, err error) {
+	return d.db.info()
 }
 
 func (d substore) Close() (err error) {
-	return d.db.Close()
+	return d.db.close()
 }
 
 // DB Wrappers to perform fatal error checks on each call.
 func (d substore) Drop(table string) (err error) {
-	return d.db.Drop(d.apply_prefix(table))
+	defer d.track("Drop", table, "", time.Now(), &err)
+	table, err = d.apply_prefix(table)
+	if err != nil {
+		return err
+	}
+	return d.db.update(func(tx txn) error {
+		return dropTables(tx, func(name string) bool {
+			return name == table || strings.HasPrefix(name, table+string(sepr))
+		})
+	})
+}
+
+// Drops tables beginning with prefix, an empty prefix is refused rather than dropping every table.
+func (d substore) DropPrefix(prefix string) (err error) {
+	defer d.track("DropPrefix", prefix, "", time.Now(), &err)
+	if prefix == "" {
+		return ErrEmptyName
+	}
+	prefix = d.prefix + escapeName(prefix)
+	return d.db.update(func(tx txn) error {
+		return dropTables(tx, func(name string) bool {
+			return strings.HasPrefix(name, prefix) && name != "KVLite"
+		})
+	})
+}
+
+// Drops tables of transaction matched by match.
+func dropTables(tx txn, match func(name string) bool) error {
+	for _, name := range tx.tables() {
+		if !match(name) {
+			continue
+		}
+		if err := tx.dropTable(name); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Encrypt value to go-kvlie, fatal on error.
-func (d substore) CryptSet(table, key string, value interface{}) error {
-	return d.db.CryptSet(d.apply_prefix(table), key, value)
+func (d substore) CryptSet(table, key string, value interface{}) (err error) {
+	defer d.track("CryptSet", table, key, time.Now(), &err)
+	table, err = d.apply_prefix(table)
+	if err != nil {
+		return err
+	}
+	return setValue(d.db, table, key, value, crypt_value)
+}
+
+// Encrypt fields tagged `kvlite:"encrypt"` of value to go-kvlite.
+func (d substore) CryptSetPartial(table, key string, value interface{}) (err error) {
+	defer d.track("CryptSetPartial", table, key, time.Now(), &err)
+	table, err = d.apply_prefix(table)
+	if err != nil {
+		return err
+	}
+	return setValue(d.db, table, key, value, partial_value)
 }
 
 // Save value to go-kvlite.
-func (d substore) Set(table, key string, value interface{}) error {
-	return d.db.Set(d.apply_prefix(table), key, value)
+func (d substore) Set(table, key string, value interface{}) (err error) {
+	defer d.track("Set", table, key, time.Now(), &err)
+	table, err = d.apply_prefix(table)
+	if err != nil {
+		return err
+	}
+	return setValue(d.db, table, key, value, plain_value)
 }
 
 // Retrieve value from go-kvlite.
-func (d substore) Get(table, key string, output interface{}) (bool, error) {
-	return d.db.Get(d.apply_prefix(table), key, output)
+func (d substore) Get(table, key string, output interface{}) (found bool, err error) {
+	defer d.track("Get", table, key, time.Now(), &err)
+	table, err = d.apply_prefix(table)
+	if err != nil {
+		return false, err
+	}
+	return getValue(d.db, table, key, output)
 }
 
 // List keys in go-kvlite.
-func (d substore) Keys(table string) ([]string, error) {
-	return d.db.Keys(d.apply_prefix(table))
+func (d substore) Keys(table string) (keys []string, err error) {
+	defer d.track("Keys", table, "", time.Now(), &err)
+	table, err = d.apply_prefix(table)
+	if err != nil {
+		return nil, err
+	}
+	err = d.db.view(func(tx txn) error {
+		bucket := tx.table(table)
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			keys = append(keys, string(k))
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// Lists up to limit keys in table following after_key, a limit less than 1 lists all remaining keys.
+func (d substore) KeysPage(table, after_key string, limit int) (keys []string, next string, err error) {
+	defer d.track("KeysPage", table, "", time.Now(), &err)
+	table, err = d.apply_prefix(table)
+	if err != nil {
+		return nil, "", err
+	}
+	err = d.db.view(func(tx txn) error {
+		bucket := tx.table(table)
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.cursor()
+		k, _ := c.Seek([]byte(after_key))
+		if k != nil && string(k) == after_key {
+			k, _ = c.Next()
+		}
+		for ; k != nil; k, _ = c.Next() {
+			if limit > 0 && len(keys) == limit {
+				next = keys[len(keys)-1]
+				break
+			}
+			keys = append(keys, string(k))
+		}
+		return nil
+	})
+	return keys, next, err
 }
 
 // Count keys in table.
-func (d substore) CountKeys(table string) (int, error) {
-	return d.db.CountKeys(d.apply_prefix(table))
+func (d substore) CountKeys(table string) (count int, err error) {
+	defer d.track("CountKeys", table, "", time.Now(), &err)
+	table, err = d.apply_prefix(table)
+	if err != nil {
+		return 0, err
+	}
+	err = d.db.view(func(tx txn) error {
+		if bucket := tx.table(table); bucket != nil {
+			count = countKeys(bucket)
+		}
+		return nil
+	})
+	return count, err
 }
 
+// Lists tables of namespace in their escaped form, limit_depth limits to first-level names.
 func (d substore) buckets(limit_depth bool) (buckets []string, err error) {
+	err = d.db.view(func(tx txn) error {
+		buckets = d.list(tx, limit_depth)
+		return nil
+	})
+	return buckets, err
+}
+
+// Lists tables of namespace within transaction, in their escaped form.
+func (d substore) list(tx txn, limit_depth bool) (buckets []string) {
 	bmap := make(map[string]struct{})
 
-	tmp, e := d.db.buckets(false)
-	if e != nil {
-		return buckets, e
-	}
-	for _, t := range tmp {
-		if strings.HasPrefix(t, d.prefix) {
-			name := strings.TrimPrefix(t, d.prefix)
-			if !limit_depth {
+	for _, t := range tx.tables() {
+		if t == "KVLite" || !strings.HasPrefix(t, d.prefix) {
+			continue
+		}
+		name := strings.TrimPrefix(t, d.prefix)
+		if !limit_depth {
+			buckets = append(buckets, name)
+		} else {
+			name = strings.Split(name, string(sepr))[0]
+			if _, ok := bmap[name]; !ok {
+				bmap[name] = struct{}{}
 				buckets = append(buckets, name)
-			} else {
-				name = strings.Split(name, string(sepr))[0]
-				if _, ok := bmap[name]; !ok {
-					bmap[name] = struct{}{}
-					buckets = append(buckets, name)
-				}
 			}
 		}
 	}
-	return buckets, err
+	return buckets
 }
 
 // List Tables in DB
 func (d substore) Tables() (buckets []string, err error) {
+	defer d.track("Tables", "", "", time.Now(), &err)
 	tmp, e := d.buckets(true)
 	if e != nil {
 		return buckets, e
 	}
-	for _, t := range tmp {
-		if name := strings.TrimPrefix(t, d.prefix); !strings.ContainsRune(name, sepr) {
-			buckets = append(buckets, name)
+	for _, name := range tmp {
+		if name != "" {
+			buckets = append(buckets, unescapeName(name))
 		}
 	}
 	return buckets, err
 }
 
 // Delete value from go-kvlite.
-func (d substore) Unset(table, key string) error {
-	return d.db.Unset(d.apply_prefix(table), key)
+func (d substore) Unset(table, key string) (err error) {
+	defer d.track("Unset", table, key, time.Now(), &err)
+	table, err = d.apply_prefix(table)
+	if err != nil {
+		return err
+	}
+	return d.db.update(func(tx txn) error {
+		bucket := tx.table(table)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.delete([]byte(key))
+	})
+}
+
+// Delete values with keys beginning with prefix from go-kvlite.
+func (d substore) UnsetPrefix(table, prefix string) (err error) {
+	defer d.track("UnsetPrefix", table, prefix, time.Now(), &err)
+	table, err = d.apply_prefix(table)
+	if err != nil {
+		return err
+	}
+	return d.db.update(func(tx txn) error {
+		bucket := tx.table(table)
+		if bucket == nil {
+			return nil
+		}
+		// Keys are collected first, as deleting through a cursor while iterating skips keys.
+		for _, k := range prefixKeys(bucket, []byte(prefix)) {
+			if err := bucket.delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Exports table of namespace, table is given in its escaped form as listed by buckets.
+func (d substore) export(table string) (records map[string][]byte, err error) {
+	if table == "" {
+		return nil, ErrEmptyName
+	}
+	records = make(map[string][]byte)
+	err = d.db.view(func(tx txn) error {
+		bucket := tx.table(d.prefix + table)
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if len(v) == 0 {
+				continue
+			}
+			v, err := d.db.codec().unseal(v)
+			if err != nil {
+				return err
+			}
+			records[string(k)] = v
+		}
+		return nil
+	})
+	return records, err
+}
+
+// Restores table of namespace in a single transaction, table is given in its escaped form as listed by buckets.
+func (d substore) restore(table string, records map[string][]byte) (err error) {
+	if table == "" {
+		return ErrEmptyName
+	}
+	if d.prefix == "" && table == "KVLite" {
+		return ErrReservedName
+	}
+	return d.db.update(func(tx txn) error {
+		bucket, err := tx.createTable(d.prefix + table)
+		if err != nil {
+			return err
+		}
+		for k, v := range records {
+			if v == nil {
+				if err = bucket.delete([]byte(k)); err != nil {
+					return err
+				}
+				continue
+			}
+			if v, err = d.db.codec().seal(v); err != nil {
+				return err
+			}
+			if err = bucket.put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // Drill in to specific table.
-func (d substore) Table(table string) Table {
-	return d.db.Table(d.apply_prefix(table))
+func (d *substore) Table(table string) Table {
+	return focused{table: table, store: d}
 }
@@ -0,0 +1,212 @@
+package kvlite
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// Returns memory and bolt stores to run a test against.
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return map[string]Store{"mem": MemStore(), "bolt": db}
+}
+
+// Returns tables of store, sorted.
+func sortedTables(t *testing.T, db Store) []string {
+	t.Helper()
+	tables, err := db.Tables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+func TestEscapeName(t *testing.T) {
+	tests := []string{
+		"",
+		"plain",
+		string(sepr),
+		"a" + string(sepr) + "b",
+		string(esc),
+		string(esc) + "0",
+		string(esc) + "1",
+		string(esc) + string(sepr) + string(esc),
+	}
+	for _, name := range tests {
+		escaped := escapeName(name)
+		for _, r := range escaped {
+			if r == sepr {
+				t.Errorf("escapeName(%q) = %q, contains separator", name, escaped)
+			}
+		}
+		if got := unescapeName(escaped); got != name {
+			t.Errorf("unescapeName(escapeName(%q)) = %q", name, got)
+		}
+	}
+}
+
+func TestTableNames(t *testing.T) {
+	tests := []struct {
+		name  string
+		store func(db Store) Store
+		table string
+		err   error
+	}{
+		{"empty at root", func(db Store) Store { return db }, "", ErrEmptyName},
+		{"empty nested", func(db Store) Store { return db.Sub("a") }, "", ErrEmptyName},
+		{"reserved at root", func(db Store) Store { return db }, "KVLite", ErrReservedName},
+		{"reserved nested", func(db Store) Store { return db.Sub("a") }, "KVLite", nil},
+		{"reserved in bucket", func(db Store) Store { return db.Bucket("a") }, "KVLite", nil},
+		{"separator at root", func(db Store) Store { return db }, "a" + string(sepr) + "b", nil},
+		{"separator nested", func(db Store) Store { return db.Sub("a").Sub("b") }, string(sepr), nil},
+		{"escape nested", func(db Store) Store { return db.Sub(string(esc)) }, string(esc) + "1", nil},
+	}
+
+	for backend, db := range testStores(t) {
+		for _, tt := range tests {
+			s := tt.store(db)
+			err := s.Set(tt.table, "key", tt.name)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: %s: Set returned %v, want %v", backend, tt.name, err, tt.err)
+				continue
+			}
+			if tt.err != nil {
+				if _, err = s.Get(tt.table, "key", nil); !errors.Is(err, tt.err) {
+					t.Errorf("%s: %s: Get returned %v, want %v", backend, tt.name, err, tt.err)
+				}
+				continue
+			}
+			var value string
+			if found, err := s.Get(tt.table, "key", &value); err != nil || !found || value != tt.name {
+				t.Errorf("%s: %s: Get returned %q, %v, %v", backend, tt.name, value, found, err)
+			}
+			tables := sortedTables(t, s)
+			if i := sort.SearchStrings(tables, tt.table); i == len(tables) || tables[i] != tt.table {
+				t.Errorf("%s: %s: Tables returned %q, missing %q", backend, tt.name, tables, tt.table)
+			}
+		}
+	}
+}
+
+func TestSeparatorIsolation(t *testing.T) {
+	for backend, db := range testStores(t) {
+		// A table named with the separator must not land in the namespace it resembles.
+		if err := db.Set("a"+string(sepr)+"b", "key", "root"); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Sub("a").Set("b", "key", "nested"); err != nil {
+			t.Fatal(err)
+		}
+
+		var value string
+		if _, err := db.Get("a"+string(sepr)+"b", "key", &value); err != nil || value != "root" {
+			t.Errorf("%s: root table read %q, %v", backend, value, err)
+		}
+		if _, err := db.Sub("a").Get("b", "key", &value); err != nil || value != "nested" {
+			t.Errorf("%s: nested table read %q, %v", backend, value, err)
+		}
+
+		if err := db.Drop("a" + string(sepr) + "b"); err != nil {
+			t.Fatal(err)
+		}
+		if found, _ := db.Sub("a").Get("b", "key", nil); !found {
+			t.Errorf("%s: dropping root table removed nested table", backend)
+		}
+	}
+}
+
+func TestNestedDropAndTables(t *testing.T) {
+	for backend, db := range testStores(t) {
+		outer := db.Sub("outer")
+		inner := outer.Sub("inner")
+
+		for _, s := range []Store{db, outer, inner} {
+			for _, table := range []string{"t1", "t2"} {
+				if err := s.Set(table, "key", true); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		tests := []struct {
+			store Store
+			want  []string
+		}{
+			{db, []string{"outer", "t1", "t2"}},
+			{outer, []string{"inner", "t1", "t2"}},
+			{inner, []string{"t1", "t2"}},
+			{db.Sub("missing"), nil},
+		}
+		for i, tt := range tests {
+			if got := sortedTables(t, tt.store); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: Tables of store %d = %q, want %q", backend, i, got, tt.want)
+			}
+		}
+
+		// Dropping a table of the inner namespace leaves its siblings.
+		if err := inner.Drop("t1"); err != nil {
+			t.Fatal(err)
+		}
+		if got := sortedTables(t, inner); !reflect.DeepEqual(got, []string{"t2"}) {
+			t.Errorf("%s: inner Tables after Drop = %q", backend, got)
+		}
+
+		// Dropping a namespace by name removes the namespaces beneath it.
+		if err := outer.Drop("inner"); err != nil {
+			t.Fatal(err)
+		}
+		if got := sortedTables(t, outer); !reflect.DeepEqual(got, []string{"t1", "t2"}) {
+			t.Errorf("%s: outer Tables after Drop = %q", backend, got)
+		}
+		if found, _ := inner.Get("t2", "key", nil); found {
+			t.Errorf("%s: inner table survived Drop of its namespace", backend)
+		}
+
+		// The Sub of an empty name is the namespace itself.
+		if got := sortedTables(t, outer.Sub("")); !reflect.DeepEqual(got, []string{"t1", "t2"}) {
+			t.Errorf("%s: Tables of empty Sub = %q", backend, got)
+		}
+
+		// Tables of the root never list the reserved table.
+		if err := DropSafe(db, "t1", DropToken("t1")); err != nil {
+			t.Fatal(err)
+		}
+		if got := sortedTables(t, db); !reflect.DeepEqual(got, []string{"outer", "t2"}) {
+			t.Errorf("%s: root Tables after DropSafe = %q", backend, got)
+		}
+	}
+}
+
+func TestDropPrefixKeepsReserved(t *testing.T) {
+	for backend, db := range testStores(t) {
+		for _, table := range []string{"Kept", "Dropped"} {
+			if err := db.Set(table, "key", true); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := DropSafe(db, "Dropped", DropToken("Dropped")); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.DropPrefix(""); !errors.Is(err, ErrEmptyName) {
+			t.Errorf("%s: DropPrefix of empty prefix returned %v", backend, err)
+		}
+		if err := db.DropPrefix("K"); err != nil {
+			t.Fatal(err)
+		}
+		if got := sortedTables(t, db); len(got) != 0 {
+			t.Errorf("%s: Tables after DropPrefix = %q", backend, got)
+		}
+		if records, err := DropHistory(db); err != nil || len(records) != 1 {
+			t.Errorf("%s: DropHistory after DropPrefix returned %d records, %v", backend, len(records), err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package kvlite
+
+import (
+	"bytes"
+)
+
+// Backend holds the tables of a database as stored values, each Store is a namespace over its backend.
+type backend interface {
+	// view runs fn within a read-only transaction.
+	view(fn func(tx txn) error) error
+	// update runs fn within a read-write transaction, discarding its changes should fn return an error.
+	update(fn func(tx txn) error) error
+	// codec returns encoder of stored values.
+	codec() encoder
+	// stats returns observer and operation counts of the database.
+	stats() *observed
+	// info returns usage statistics of the database.
+	info() (info Info, err error)
+	// close closes the database.
+	close() error
+}
+
+// Transaction of a backend, tables are named in full, including their namespace.
+type txn interface {
+	// table returns table, nil if it does not exist.
+	table(name string) txTable
+	// createTable returns table, creating it if it does not exist.
+	createTable(name string) (txTable, error)
+	// dropTable deletes table, if it exists.
+	dropTable(name string) error
+	// tables lists names of all tables in order, including the reserved KVLite table.
+	tables() []string
+}
+
+// Table within a transaction, values returned are only valid until the transaction ends.
+type txTable interface {
+	get(key []byte) []byte
+	put(key, value []byte) error
+	delete(key []byte) error
+	cursor() txCursor
+	nextSequence() (uint64, error)
+}
+
+// Cursor over keys of a table in byte order, a nil key marks the end of the table.
+type txCursor interface {
+	First() (key, value []byte)
+	Seek(seek []byte) (key, value []byte)
+	Next() (key, value []byte)
+}
+
+// Returns copies of keys of table beginning with prefix, so they may be deleted once collected.
+func prefixKeys(t txTable, prefix []byte) (keys [][]byte) {
+	c := t.cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		keys = append(keys, append([]byte{}, k...))
+	}
+	return keys
+}
+
+// Counts keys of table.
+func countKeys(t txTable) (count int) {
+	c := t.cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		count++
+	}
+	return count
+}
+
+// Retrieves value at key of table in to output.
+func getValue(db backend, table, key string, output interface{}) (found bool, err error) {
+	err = db.view(func(tx txn) error {
+		t := tx.table(table)
+		if t == nil {
+			return nil
+		}
+		data := t.get([]byte(key))
+		if data == nil {
+			return nil
+		}
+		found = true
+		if output == nil {
+			return nil
+		}
+		return db.codec().decode(data, output)
+	})
+	return found, err
+}
+
+// Stores value at key of table, marked with how it is encrypted.
+func setValue(db backend, table, key string, value interface{}, marker byte) (err error) {
+	v, err := db.codec().record(value, marker)
+	if err != nil {
+		return err
+	}
+	return db.update(func(tx txn) error {
+		t, err := tx.createTable(table)
+		if err != nil {
+			return err
+		}
+		return t.put([]byte(key), v)
+	})
+}
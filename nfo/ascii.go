@@ -0,0 +1,81 @@
+package nfo
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+var ascii_output int32
+
+func init() {
+	if legacyConsole() {
+		ascii_output = 1
+	}
+}
+
+// Replaces non-ASCII glyphs of progress bars and other Flash output with ASCII, for consoles lacking UTF-8.
+// Enabled by default on Windows consoles not using the UTF-8 code page.
+func SetASCII(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&ascii_output, 1)
+	} else {
+		atomic.StoreInt32(&ascii_output, 0)
+	}
+}
+
+// ASCII replacements of common glyphs, other non-ASCII runes are replaced with '?'.
+var ascii_glyphs = map[rune]string{
+	'░': "#",
+	'▒': "#",
+	'▓': "#",
+	'█': "#",
+	'…': "...",
+	'–': "-",
+	'—': "-",
+	'‘': "'",
+	'’': "'",
+	'“': "\"",
+	'”': "\"",
+	'•': "*",
+	'←': "<-",
+	'→': "->",
+}
+
+// Returns text with non-ASCII runes transliterated when ASCII output is enabled.
+func asciiText(text string) string {
+	if atomic.LoadInt32(&ascii_output) == 0 {
+		return text
+	}
+
+	var i int
+	for i = 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			break
+		}
+	}
+	if i == len(text) {
+		return text
+	}
+
+	var b strings.Builder
+	b.WriteString(text[:i])
+	for _, r := range text[i:] {
+		switch {
+		case r < 0x80:
+			b.WriteRune(r)
+		case ascii_glyphs[r] != "":
+			b.WriteString(ascii_glyphs[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// Returns glyph filling progress bars.
+func progressGlyph() rune {
+	if atomic.LoadInt32(&ascii_output) != 0 {
+		return '#'
+	}
+	return '░'
+}
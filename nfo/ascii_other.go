@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package nfo
+
+// Consoles are expected to support UTF-8.
+func legacyConsole() bool {
+	return false
+}
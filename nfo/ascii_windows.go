@@ -0,0 +1,15 @@
+package nfo
+
+import (
+	"syscall"
+)
+
+const cp_utf8 = 65001
+
+var procGetConsoleOutputCP = syscall.NewLazyDLL("kernel32.dll").NewProc("GetConsoleOutputCP")
+
+// Returns true if the console is not using the UTF-8 code page.
+func legacyConsole() bool {
+	cp, _, _ := procGetConsoleOutputCP.Call()
+	return cp != 0 && cp != cp_utf8
+}
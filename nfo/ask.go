@@ -4,30 +4,79 @@ package nfo
 import (
 	"fmt"
 	"golang.org/x/crypto/ssh/terminal"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 )
 
 var cancel = make(chan struct{})
 
+// PromptStyle defines the look applied to all interactive prompts.
+type PromptStyle struct {
+	Prefix string // Glyph placed before each prompt, ie.. "? ".
+	Color  string // ANSI color applied to prefix, ie.. "\033[36m", ignored when stdout is piped.
+	Indent int    // Number of spaces placed before prefix.
+}
+
+var prompt_style PromptStyle
+
+// Sets style applied by GetInput, GetSecret, GetConfirm, GetSelect and PressEnter.
+func SetPromptStyle(style PromptStyle) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	prompt_style = style
+}
+
+// Applies prompt style to prompt.
+func styledPrompt(prompt string) string {
+	mutex.Lock()
+	style := prompt_style
+	mutex.Unlock()
+
+	prefix := style.Prefix
+	if prefix != "" && style.Color != "" && !piped_stdout {
+		prefix = fmt.Sprintf("%s%s\033[0m", style.Color, prefix)
+	}
+	return fmt.Sprintf("%s%s%s", strings.Repeat(" ", style.Indent), prefix, prompt)
+}
+
+// Returns indentation lining up with text of a styled prompt.
+func promptIndent() string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return strings.Repeat(" ", prompt_style.Indent+len([]rune(prompt_style.Prefix)))
+}
+
 // Function to restore terminal on event we get an interuption.
 func getEscape() func() {
-	s, _ := terminal.GetState(int(syscall.Stdin))
+	s, err := terminal.GetState(int(syscall.Stdin))
+	if err != nil {
+		return func() {}
+	}
 	return func() { terminal.Restore(int(syscall.Stdin), s) }
 }
 
 // Loop until a non-blank answer is given
 func NeedAnswer(prompt string, request func(prompt string) string) (output string) {
-	for output = request(prompt); output == ""; output = request(prompt) {
+	for output = request(prompt); output == "" && !stdinDone(); output = request(prompt) {
 	}
 	return output
 }
 
 // Prompt to press enter.
 func PressEnter(prompt string) {
+	if piped_stdin {
+		return
+	}
+
+	Pause()
+	defer Resume()
+
 	unesc := Defer(getEscape())
 	defer unesc()
 
+	prompt = styledPrompt(prompt)
 	fmt.Printf("\r%s", prompt)
 
 	var blank_line []rune
@@ -38,12 +87,97 @@ func PressEnter(prompt string) {
 	fmt.Printf("\r%s\r", string(blank_line))
 }
 
+// Prompt to press enter, showing a countdown and continuing automatically once timeout elapses.
+// Returns true if enter was pressed before timeout.
+func PressEnterTimeout(prompt string, timeout time.Duration) bool {
+	if piped_stdin {
+		return false
+	}
+
+	Pause()
+	defer Resume()
+
+	unesc := Defer(getEscape())
+	defer unesc()
+
+	prompt = styledPrompt(prompt)
+
+	// Raw mode passes keys as typed without echo, so the wait for enter ends with the countdown rather than leaving a read behind.
+	if _, err := terminal.MakeRaw(int(syscall.Stdin)); err != nil {
+		return false
+	}
+
+	clock := PleaseWait.getClock()
+	deadline := clock.Now().Add(timeout)
+
+	var last_len int
+
+	show := func() {
+		remaining := deadline.Sub(clock.Now()).Round(time.Second)
+		if remaining < 0 {
+			remaining = 0
+		}
+		line := fmt.Sprintf("\r%s (%s) ", prompt, remaining)
+		if pad := last_len - len(line); pad > 0 {
+			line = line + strings.Repeat(" ", pad)
+		}
+		last_len = len(line)
+		fmt.Print(line)
+	}
+
+	erase := func() {
+		fmt.Printf("\r%s\r", strings.Repeat(" ", last_len))
+	}
+
+	show()
+
+	for {
+		wait := deadline.Sub(clock.Now())
+		if wait <= 0 {
+			erase()
+			return false
+		}
+		if wait > time.Second {
+			wait = time.Second
+		}
+		pressed, err := waitEnter(wait)
+		if pressed || err != nil {
+			erase()
+			return pressed
+		}
+		show()
+	}
+}
+
+// Returns true if keys read from a raw terminal include enter, passing an interrupt on to the signal handler.
+func enterKey(keys []byte) bool {
+	for _, k := range keys {
+		switch k {
+		case '\r', '\n':
+			return true
+		case 3:
+			signalChan <- syscall.SIGINT
+		}
+	}
+	return false
+}
+
 // Get Hidden/Password input, without returning information to the screen.
 func GetSecret(prompt string) string {
+	Pause()
+	defer Resume()
+
+	if piped_stdin {
+		fmt.Print(styledPrompt(prompt))
+		line, _ := readPipedLine()
+		fmt.Printf("\n")
+		return cleanInput(line)
+	}
+
 	unesc := Defer(getEscape())
 	defer unesc()
 
-	fmt.Printf(prompt)
+	fmt.Print(styledPrompt(prompt))
 	resp, _ := terminal.ReadPassword(int(syscall.Stdin))
 	output := cleanInput(string(resp))
 	fmt.Printf("\n")
@@ -57,13 +191,40 @@ func GetConfirm(prompt string) bool {
 		resp = strings.ToLower(resp)
 		if resp == "y" || resp == "yes" {
 			return true
-		} else if resp == "n" || resp == "no" {
+		} else if resp == "n" || resp == "no" || stdinDone() {
 			return false
 		}
 		continue
 	}
 }
 
+// Get selection from list of choices, returns index of choice.
+func GetSelect(prompt string, choices []string) int {
+	if len(choices) == 0 {
+		return -1
+	}
+
+	Pause()
+	defer Resume()
+
+	fmt.Println(styledPrompt(prompt))
+
+	indent := promptIndent()
+	for i, v := range choices {
+		fmt.Printf("%s%d) %s\n", indent, i+1, v)
+	}
+
+	for {
+		resp := GetInput(fmt.Sprintf("Select (1-%d): ", len(choices)))
+		if n, err := strconv.Atoi(resp); err == nil && n > 0 && n <= len(choices) {
+			return n - 1
+		}
+		if stdinDone() {
+			return -1
+		}
+	}
+}
+
 // Removes newline characters
 func cleanInput(input string) (output string) {
 	var output_bytes []rune
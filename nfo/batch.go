@@ -0,0 +1,124 @@
+package nfo
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"time"
+)
+
+var (
+	batch_size    int
+	batch_stop    chan struct{}
+	batch_pending = make(map[*fileSink]*bytes.Buffer)
+	batch_order   []*fileSink
+)
+
+// Log file of one or more loggers, identified by pointer as the writer it holds may not be comparable.
+type fileSink struct {
+	w io.Writer
+}
+
+// Returns sink for w, shared with loggers already writing to w, mutex must be held by caller.
+func newSink(w io.Writer) *fileSink {
+	if w == nil || w == None {
+		return nil
+	}
+	// Writers of types that can't be compared are shared only by loggers set in the same call, as comparing them panics.
+	if reflect.TypeOf(w).Comparable() {
+		for _, v := range l_map {
+			if v.fileout != nil && v.fileout.w == w {
+				return v.fileout
+			}
+		}
+	}
+	return &fileSink{w}
+}
+
+// Buffers log file writes, flushing once size bytes are pending for a file or latency has passed since the last flush.
+// Pending writes are flushed on shutdown, or by calling Flush. A size of 0 disables batching.
+func SetBatching(size int, latency time.Duration) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if batch_stop != nil {
+		close(batch_stop)
+		batch_stop = nil
+	}
+
+	flushBatches()
+	batch_size = size
+
+	if size <= 0 || latency <= 0 {
+		return
+	}
+
+	batch_stop = make(chan struct{})
+
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(latency)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				mutex.Lock()
+				flushBatches()
+				mutex.Unlock()
+			}
+		}
+	}(batch_stop)
+}
+
+// Writes pending log file entries to disk.
+func Flush() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	flushBatches()
+}
+
+// Writes data to log file s, holding it back when batching is enabled, mutex must be held by caller.
+func writeFile(s *fileSink, data []byte) (err error) {
+	if s == nil {
+		return nil
+	}
+
+	if batch_size <= 0 {
+		_, err = s.w.Write(data)
+		return err
+	}
+
+	buf, ok := batch_pending[s]
+	if !ok {
+		buf = new(bytes.Buffer)
+		batch_pending[s] = buf
+		batch_order = append(batch_order, s)
+	}
+	buf.Write(data)
+
+	if buf.Len() >= batch_size {
+		_, err = s.w.Write(buf.Bytes())
+		buf.Reset()
+	}
+	return err
+}
+
+// Writes all pending log file entries, mutex must be held by caller.
+func flushBatches() {
+	for _, s := range batch_order {
+		flushWriter(s)
+	}
+}
+
+// Writes pending log file entries of s, mutex must be held by caller.
+func flushWriter(s *fileSink) {
+	buf, ok := batch_pending[s]
+	if !ok || buf.Len() == 0 {
+		return
+	}
+	if _, err := s.w.Write(buf.Bytes()); err != nil && FatalOnFileError {
+		go Fatal(err)
+	}
+	buf.Reset()
+}
@@ -0,0 +1,110 @@
+package nfo
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// WriteCloser of a type that can't be compared, passing writes to write.
+type funcWriter struct {
+	write func(p []byte)
+}
+
+func (f funcWriter) Write(p []byte) (int, error) {
+	f.write(p)
+	return len(p), nil
+}
+
+func (f funcWriter) Close() error {
+	return nil
+}
+
+func TestBatchUncomparableFile(t *testing.T) {
+	mutex.Lock()
+	info, errs := l_map[INFO], l_map[ERROR]
+	saved := []_logger{*info, *errs}
+	mutex.Unlock()
+	defer func() {
+		SetBatching(0, 0)
+		mutex.Lock()
+		defer mutex.Unlock()
+		*info, *errs = saved[0], saved[1]
+	}()
+
+	var buf bytes.Buffer
+	SetOutput(INFO|ERROR, io.Discard)
+	SetFile(INFO|ERROR, funcWriter{func(p []byte) { buf.Write(p) }})
+	SetBatching(1<<20, 0)
+
+	Log("first entry")
+	Err("second entry")
+	write2files("marker")
+
+	if buf.Len() != 0 {
+		t.Errorf("batched entries written before Flush: %q", buf.String())
+	}
+	Flush()
+
+	out := buf.String()
+	for _, want := range []string{"first entry", "second entry"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("file missing %q, got %q", want, out)
+		}
+	}
+	if n := strings.Count(out, "marker"); n != 1 {
+		t.Errorf("marker written %d times to file shared by loggers, want 1", n)
+	}
+	if !strings.Contains(DumpState(), "nfo.funcWriter") {
+		t.Error("DumpState does not list file")
+	}
+}
+
+func TestBatchAddFileClose(t *testing.T) {
+	mutex.Lock()
+	info := l_map[INFO]
+	saved := *info
+	mutex.Unlock()
+	defer func() {
+		SetBatching(0, 0)
+		mutex.Lock()
+		defer mutex.Unlock()
+		*info = saved
+	}()
+
+	name := filepath.Join(t.TempDir(), "test.log")
+	f, err := LogFile(name, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Take the close hook LogFile registered, to run it as shutdown would.
+	globalDefer.mutex.Lock()
+	id := globalDefer.ids[len(globalDefer.ids)-1]
+	close_file := globalDefer.d_map[id]
+	globalDefer.ids = globalDefer.ids[:len(globalDefer.ids)-1]
+	delete(globalDefer.d_map, id)
+	globalDefer.mutex.Unlock()
+
+	var added bytes.Buffer
+	SetOutput(INFO, io.Discard)
+	SetFile(INFO, f)
+	AddFile(INFO, &added)
+	SetBatching(1<<20, time.Hour)
+
+	Log("batched entry")
+	if err := close_file(); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(name); !strings.Contains(string(data), "batched entry") {
+		t.Errorf("log file holds %q after close", data)
+	}
+	if !strings.Contains(added.String(), "batched entry") {
+		t.Errorf("added file holds %q after close", added.String())
+	}
+}
@@ -0,0 +1,199 @@
+package nfo
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"hash"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckpointTable stores checkpoints of transfers, keyed by name, a kvlite.Table satisfies it.
+type CheckpointTable interface {
+	Get(key string, output interface{}) (found bool, err error)
+	Set(key string, value interface{}) (err error)
+	Unset(key string) (err error)
+}
+
+var checkpoints struct {
+	mutex    sync.RWMutex
+	table    CheckpointTable
+	interval time.Duration
+}
+
+// Checkpoint records progress of a monitored transfer, written by transfers started while SetCheckpoints is enabled.
+type Checkpoint struct {
+	Name   string
+	Offset int64     // Bytes read from the start of source.
+	Total  int64     // Total size of the transfer.
+	Hash   []byte    // Marshaled SHA-256 state of the bytes before Offset.
+	Time   time.Time // Time checkpoint was written.
+}
+
+// Checkpoints monitored transfers to table, keyed by name, at most once per interval, a nil table disables checkpoints.
+// Checkpoints of transfers read to completion are removed, others are kept for ResumeTransferMonitor.
+func SetCheckpoints(table CheckpointTable, interval time.Duration) {
+	checkpoints.mutex.Lock()
+	defer checkpoints.mutex.Unlock()
+	checkpoints.table = table
+	checkpoints.interval = interval
+}
+
+// Returns checkpoint table, nil when checkpoints are disabled.
+func checkpointTable() CheckpointTable {
+	checkpoints.mutex.RLock()
+	defer checkpoints.mutex.RUnlock()
+	return checkpoints.table
+}
+
+// Adds a transfer monitor to source, resuming from the offset of its last checkpoint.
+// Total size is found by seeking to the end of source, the transfer starts over if the checkpoint is missing or its size differs.
+func ResumeTransferMonitor(name string, source ReadSeekCloser) (ReadSeekCloser, error) {
+	total, err := source.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		cp     Checkpoint
+		offset int64
+	)
+
+	h := sha256.New()
+
+	if table := checkpointTable(); table != nil {
+		found, err := table.Get(name, &cp)
+		if err != nil {
+			return nil, err
+		}
+		if found && cp.Total == total && cp.Offset <= total {
+			if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(cp.Hash); err == nil {
+				offset = cp.Offset
+			} else {
+				h.Reset()
+			}
+		}
+	}
+
+	if _, err = source.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	tm := TransferMonitor(name, total, LeftToRight, source).(*tmon)
+	atomic.StoreInt64(&tm.transferred, offset)
+	tm.offset = offset
+	if tm.hash != nil {
+		tm.hash = h
+	}
+
+	return tm, nil
+}
+
+// Returns SHA-256 sum of bytes read through transfer, nil if not a transfer started while checkpoints were enabled.
+// A resumed transfer includes bytes read before its checkpoint.
+func TransferChecksum(transfer ReadSeekCloser) []byte {
+	tm, ok := transfer.(*tmon)
+	if !ok {
+		return nil
+	}
+	tm.hash_lock.Lock()
+	defer tm.hash_lock.Unlock()
+	if tm.hash == nil {
+		return nil
+	}
+	return tm.hash.Sum(nil)
+}
+
+// Returns hash for new transfer when checkpoints are enabled.
+func checkpointHash() hash.Hash {
+	if checkpointTable() == nil {
+		return nil
+	}
+	return sha256.New()
+}
+
+// Hashes bytes read, writing a checkpoint once the interval has passed since the last.
+func (tm *tmon) checkpoint(p []byte) {
+	tm.hash_lock.Lock()
+	defer tm.hash_lock.Unlock()
+
+	if tm.hash == nil {
+		return
+	}
+	tm.hash.Write(p)
+
+	checkpoints.mutex.RLock()
+	interval := checkpoints.interval
+	checkpoints.mutex.RUnlock()
+
+	if tm.clock.Now().Sub(tm.saved) < interval {
+		return
+	}
+	tm.saveCheckpoint()
+}
+
+// Writes checkpoint of transfer, hash_lock must be held by caller.
+func (tm *tmon) saveCheckpoint() {
+	table := checkpointTable()
+	if table == nil {
+		return
+	}
+
+	state, err := tm.hash.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		Err("%s: checkpoint failed: %s", tm.name, err)
+		return
+	}
+
+	tm.saved = tm.clock.Now()
+	cp := Checkpoint{
+		Name:   tm.name,
+		Offset: atomic.LoadInt64(&tm.transferred),
+		Total:  tm.total_size,
+		Hash:   state,
+		Time:   tm.saved,
+	}
+	if err := table.Set(tm.name, &cp); err != nil {
+		Err("%s: checkpoint failed: %s", tm.name, err)
+	}
+}
+
+// Removes checkpoint of a completed transfer, or writes a final checkpoint of an incomplete one.
+func (tm *tmon) closeCheckpoint() {
+	tm.hash_lock.Lock()
+	defer tm.hash_lock.Unlock()
+
+	if tm.hash == nil {
+		return
+	}
+
+	table := checkpointTable()
+	if table == nil {
+		return
+	}
+
+	if atomic.LoadInt64(&tm.transferred) == tm.total_size && !tm.flag.Has(trans_error|trans_timeout) {
+		if err := table.Unset(tm.name); err != nil {
+			Err("%s: checkpoint failed: %s", tm.name, err)
+		}
+		return
+	}
+	tm.saveCheckpoint()
+}
+
+// Resets hash when transfer seeks back to the start, a seek elsewhere leaves the hash unusable, ending checkpoints.
+func (tm *tmon) seekCheckpoint(from, to int64) {
+	tm.hash_lock.Lock()
+	defer tm.hash_lock.Unlock()
+
+	if tm.hash == nil || from == to {
+		return
+	}
+	if to == 0 {
+		tm.hash.Reset()
+	} else {
+		tm.hash = nil
+	}
+}
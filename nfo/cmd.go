@@ -0,0 +1,82 @@
+package nfo
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Runs cmd, logging each line of its standard output to logger stdout_level and standard error to stderr_level, prefixed by the command name.
+// Returns the exit status of cmd, err is only set when cmd could not be run or its output could not be read.
+func RunCmd(cmd *exec.Cmd, stdout_level, stderr_level uint32) (exit_code int, err error) {
+	if cmd.Stdout != nil || cmd.Stderr != nil {
+		return -1, errors.New("Stdout or Stderr of command already set.")
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return -1, err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return -1, err
+	}
+
+	prefix := filepath.Base(cmd.Path) + ": "
+
+	if err = cmd.Start(); err != nil {
+		return -1, err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		read_err [2]error
+	)
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		read_err[0] = logLines(stdout, stdout_level, prefix)
+	}()
+	go func() {
+		defer wg.Done()
+		read_err[1] = logLines(stderr, stderr_level, prefix)
+	}()
+	wg.Wait()
+
+	if err = cmd.Wait(); err != nil {
+		if e, ok := err.(*exec.ExitError); ok {
+			return e.ExitCode(), nil
+		}
+		return -1, err
+	}
+
+	for _, err = range read_err {
+		if err != nil {
+			return cmd.ProcessState.ExitCode(), err
+		}
+	}
+
+	return cmd.ProcessState.ExitCode(), nil
+}
+
+// Logs each line read from r to logger flag with prefix.
+func logLines(r io.Reader, flag uint32, prefix string) error {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			write2log(flag, prefix+strings.TrimRight(line, "\r\n"))
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package nfo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+var crash struct {
+	dir     string
+	size    int
+	entries []string
+	next    int
+}
+
+// Enables writing of a crash report to dir on Fatal or a panic caught by Exit, an empty dir disables crash reports.
+// Reports hold the message, stacks of all goroutines, the last num_entries log entries and a summary of the environment.
+func CrashReports(dir string, num_entries int) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if num_entries < 0 {
+		num_entries = 0
+	}
+
+	crash.dir = dir
+	crash.size = num_entries
+	crash.entries = nil
+	crash.next = 0
+}
+
+// Records log entry for crash reports, mutex must be held by caller.
+func recordEntry(entry []byte, has_ts bool) {
+	if crash.dir == "" || crash.size == 0 {
+		return
+	}
+
+	var ts []byte
+	if !has_ts {
+		genTS(&ts)
+	}
+
+	line := strings.TrimRight(string(append(ts, entry...)), "\n")
+
+	if len(crash.entries) < crash.size {
+		crash.entries = append(crash.entries, line)
+		return
+	}
+	crash.entries[crash.next] = line
+	crash.next = (crash.next + 1) % crash.size
+}
+
+// Writes crash report, returning the name of the file written.
+func writeCrashReport(message string) (string, error) {
+	mutex.Lock()
+	dir := crash.dir
+	entries := append(append([]string{}, crash.entries[crash.next:]...), crash.entries[:crash.next]...)
+	mutex.Unlock()
+
+	if dir == "" {
+		return "", nil
+	}
+
+	now := time.Now()
+
+	stack := make([]byte, 1<<20)
+	stack = stack[:runtime.Stack(stack, true)]
+
+	host, _ := os.Hostname()
+	wd, _ := os.Getwd()
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "Crash Report: %s\n\n", now.Format(time.RFC3339))
+	fmt.Fprintf(&buf, "Message:\n%s\n\n", message)
+	fmt.Fprintf(&buf, "Environment:\n")
+	fmt.Fprintf(&buf, "  Command: %s\n", strings.Join(os.Args, " "))
+	fmt.Fprintf(&buf, "  Host: %s, PID: %d\n", host, os.Getpid())
+	fmt.Fprintf(&buf, "  Directory: %s\n", wd)
+	fmt.Fprintf(&buf, "  Go: %s %s/%s, CPUs: %d, Goroutines: %d\n\n", runtime.Version(), runtime.GOOS, runtime.GOARCH, runtime.NumCPU(), runtime.NumGoroutine())
+	fmt.Fprintf(&buf, "Recent Entries:\n")
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "  %s\n", e)
+	}
+	fmt.Fprintf(&buf, "\nStack:\n%s\n", stack)
+
+	if err := mkDir(filepath.Clean(dir) + string(os.PathSeparator)); err != nil {
+		return "", err
+	}
+
+	fname := filepath.Join(dir, fmt.Sprintf("crash-%s-%d.txt", now.Format("20060102-150405"), os.Getpid()))
+	return fname, os.WriteFile(fname, buf.Bytes(), 0600)
+}
@@ -111,6 +111,7 @@ func Exit(exit_code int) {
 		Fatal("(panic) %s", string(debug.Stack()))
 	} else {
 		atomic.StoreInt32(&fatal_triggered, 2) // Ignore any Fatal() calls, we've been told to exit.
+		errCode = exit_code
 		signalChan <- os.Kill
 		<-exit_lock
 		os.Exit(exit_code)
@@ -165,6 +166,15 @@ func init() {
 			break
 		}
 
+		// Log summary of run when in quiet summary mode, while log files are still open.
+		logSummary()
+
+		// Log number of actions skipped when in dry run mode.
+		logDryRun()
+
+		// Write out batched log file entries before deferred functions close the files.
+		Flush()
+
 		globalDefer.mutex.RLock()
 		defer globalDefer.mutex.RUnlock()
 
@@ -185,6 +195,10 @@ func init() {
 
 		// Try to flush out any remaining text.
 		write2log(_flash_txt|_no_logging|_bypass_lock, "")
+		SetFlashLines(0)
+
+		// Write out entries batched since.
+		Flush()
 
 		// Finally exit the application
 		select {
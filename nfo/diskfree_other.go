@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly && !windows
+// +build !linux,!darwin,!freebsd,!dragonfly,!windows
+
+package nfo
+
+// Free disk space is not available on this platform.
+func diskFree(path string) (uint64, error) {
+	return 0, errDiskFree
+}
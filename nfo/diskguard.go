@@ -0,0 +1,61 @@
+package nfo
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DiskDropDebug = 1 << iota // Drop DEBUG and TRACE entries from log files while disk space is low.
+	DiskPause                 // Pause all writes to log files while disk space is low.
+)
+
+// Current disk guard mode, 0 when disk space is sufficient.
+var disk_guard uint32
+
+// Returns true if the entry should not be written to log files.
+func diskGuarded(flag uint32) bool {
+	switch mode := atomic.LoadUint32(&disk_guard); {
+	case mode&DiskPause != 0:
+		return true
+	case mode&DiskDropDebug != 0:
+		return flag&(DEBUG|TRACE) != 0
+	}
+	return false
+}
+
+// Watches free space on the volume holding path every interval, when free space drops below min_free_mb log files are switched
+// to the degraded mode specified (DiskDropDebug or DiskPause) and a warning is raised, normal logging resumes once space recovers.
+func DiskGuard(path string, min_free_mb uint64, interval time.Duration, mode int) error {
+	if _, err := diskFree(path); err != nil {
+		return err
+	}
+
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	min_free := min_free_mb * 1048576
+
+	go func() {
+		for !ShutdownInProgress() {
+			free, err := diskFree(path)
+			if err != nil {
+				Warn("Unable to check free disk space on %s: %s", path, err.Error())
+			} else if free < min_free {
+				if atomic.SwapUint32(&disk_guard, uint32(mode)) == 0 {
+					Warn("Low disk space on %s (%s free), log files switched to degraded mode.", path, HumanSize(int64(free)))
+				}
+			} else if atomic.SwapUint32(&disk_guard, 0) != 0 {
+				Notice("Disk space on %s recovered (%s free), log files resumed.", path, HumanSize(int64(free)))
+			}
+			time.Sleep(interval)
+		}
+	}()
+
+	return nil
+}
+
+// Error returned when free disk space cannot be determined on this platform.
+var errDiskFree = errors.New("Checking free disk space is not supported on this platform.")
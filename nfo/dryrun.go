@@ -0,0 +1,46 @@
+package nfo
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+var dry_run struct {
+	enabled int32
+	actions int64
+}
+
+// Enables dry run mode, where Action logs actions with a DRYRUN prefix rather than allowing them to be carried out.
+// The number of actions skipped is logged on exit.
+func SetDryRun(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&dry_run.enabled, 1)
+	} else {
+		atomic.StoreInt32(&dry_run.enabled, 0)
+	}
+}
+
+// Returns true if dry run mode is enabled.
+func DryRun() bool {
+	return atomic.LoadInt32(&dry_run.enabled) != 0
+}
+
+// Logs action to be taken, returning true if it should be carried out.
+// In dry run mode the action is logged with a DRYRUN prefix and false is returned, ie.. if nfo.Action("Removing %s.", file) { os.Remove(file) }
+func Action(format string, args ...interface{}) bool {
+	if !DryRun() {
+		write2log(INFO, fmt.Sprintf(format, args...))
+		return true
+	}
+	atomic.AddInt64(&dry_run.actions, 1)
+	write2log(INFO, "[DRYRUN] "+fmt.Sprintf(format, args...))
+	return false
+}
+
+// Logs number of actions skipped when in dry run mode.
+func logDryRun() {
+	if !DryRun() {
+		return
+	}
+	write2log(INFO|_bypass_lock, "[DRYRUN] %d actions skipped.", atomic.LoadInt64(&dry_run.actions))
+}
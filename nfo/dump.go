@@ -0,0 +1,85 @@
+package nfo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+var dumpChan chan os.Signal
+
+// Dumps stacks of all goroutines along with open log files, active transfers and pending defers to the ERROR log on receiving any of sig, rather than shutting down.
+// SIGQUIT is used when no signal is given, the signals should not also be passed to SetSignals.
+func DumpOnSignal(sig ...os.Signal) {
+	if len(sig) == 0 {
+		sig = append(sig, syscall.SIGQUIT)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if dumpChan == nil {
+		dumpChan = make(chan os.Signal, 1)
+		go func() {
+			for s := range dumpChan {
+				Err("Received %s, dumping state.\n%s", s, DumpState())
+			}
+		}()
+	}
+
+	signal.Stop(dumpChan)
+	signal.Notify(dumpChan, sig...)
+}
+
+// Returns stacks of all goroutines along with open log files, active transfers and pending defers.
+func DumpState() string {
+	var buf bytes.Buffer
+
+	mutex.Lock()
+	sinks := fileSinks()
+	mutex.Unlock()
+
+	fmt.Fprintf(&buf, "Log Files:\n")
+	for _, s := range sinks {
+		fmt.Fprintf(&buf, "  %s\n", writerName(s.w))
+	}
+
+	transferDisplay.update_lock.RLock()
+	monitors := append([]*tmon{}, transferDisplay.monitors...)
+	transferDisplay.update_lock.RUnlock()
+
+	fmt.Fprintf(&buf, "Active Transfers:\n")
+	for _, t := range monitors {
+		if t.flag.Has(trans_closed) {
+			continue
+		}
+		fmt.Fprintf(&buf, "  %s: %s of %s, running %s\n", t.name, HumanSize(atomic.LoadInt64(&t.transferred)), HumanSize(t.total_size), t.clock.Now().Sub(t.start_time).Round(time.Second))
+	}
+
+	globalDefer.mutex.RLock()
+	pending := len(globalDefer.ids)
+	globalDefer.mutex.RUnlock()
+
+	fmt.Fprintf(&buf, "Pending Defers: %d\n", pending)
+	fmt.Fprintf(&buf, "Goroutines: %d\n\n", runtime.NumGoroutine())
+
+	stack := make([]byte, 1<<20)
+	stack = stack[:runtime.Stack(stack, true)]
+	buf.Write(stack)
+
+	return buf.String()
+}
+
+// Returns name of file behind w, or its type when it has no name.
+func writerName(w io.Writer) string {
+	if n, ok := w.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", w)
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly && !windows
+// +build !linux,!darwin,!freebsd,!dragonfly,!windows
+
+package nfo
+
+import "time"
+
+// Terminal input cannot be waited on with a timeout on this platform, so the countdown always runs out.
+func waitEnter(timeout time.Duration) (pressed bool, err error) {
+	time.Sleep(timeout)
+	return false, nil
+}
@@ -0,0 +1,32 @@
+//go:build linux || darwin || freebsd || dragonfly
+// +build linux darwin freebsd dragonfly
+
+package nfo
+
+import (
+	"golang.org/x/sys/unix"
+	"io"
+	"syscall"
+	"time"
+)
+
+// Waits up to timeout for enter on the terminal, which must be in raw mode, discarding other keys.
+func waitEnter(timeout time.Duration) (pressed bool, err error) {
+	fds := []unix.PollFd{{Fd: int32(syscall.Stdin), Events: unix.POLLIN}}
+	n, err := unix.Poll(fds, int(timeout/time.Millisecond))
+	if err == unix.EINTR {
+		return false, nil
+	}
+	if err != nil || n == 0 {
+		return false, err
+	}
+
+	keys := make([]byte, 64)
+	if n, err = syscall.Read(syscall.Stdin, keys); err != nil {
+		return false, err
+	}
+	if n == 0 {
+		return false, io.EOF
+	}
+	return enterKey(keys[:n]), nil
+}
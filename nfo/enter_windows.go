@@ -0,0 +1,48 @@
+package nfo
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const key_event = 0x0001
+
+var procReadConsoleInput = syscall.NewLazyDLL("kernel32.dll").NewProc("ReadConsoleInputW")
+
+// INPUT_RECORD of a console key event.
+type inputRecord struct {
+	EventType uint16
+	_         uint16
+	KeyDown   int32
+	Repeat    uint16
+	KeyCode   uint16
+	ScanCode  uint16
+	Char      uint16
+	State     uint32
+}
+
+// Waits up to timeout for enter on the console, which must be in raw mode, discarding other keys and events.
+func waitEnter(timeout time.Duration) (pressed bool, err error) {
+	event, err := syscall.WaitForSingleObject(syscall.Stdin, uint32(timeout/time.Millisecond))
+	if err != nil || event != syscall.WAIT_OBJECT_0 {
+		return false, err
+	}
+
+	var (
+		records [16]inputRecord
+		n       uint32
+	)
+
+	if r, _, err := procReadConsoleInput.Call(uintptr(syscall.Stdin), uintptr(unsafe.Pointer(&records[0])), uintptr(len(records)), uintptr(unsafe.Pointer(&n))); r == 0 {
+		return false, err
+	}
+
+	var keys []byte
+	for _, r := range records[:n] {
+		if r.EventType == key_event && r.KeyDown != 0 && r.Char < 0x80 {
+			keys = append(keys, byte(r.Char))
+		}
+	}
+	return enterKey(keys), nil
+}
@@ -0,0 +1,94 @@
+package nfo
+
+import (
+	"errors"
+	"strings"
+)
+
+// ExitError carries an exit code and user facing message, along with the error that caused it.
+type ExitError struct {
+	Code    int    // Exit code used by Fatal and ExitWith.
+	Message string // User facing message.
+	Err     error  // Wrapped error, may be nil.
+}
+
+// Returns message followed by the wrapped error.
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	if e.Message == "" {
+		return e.Err.Error()
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Returns wrapped error.
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// Creates error carrying exit code and user facing message, wrapping the error that caused it.
+func Error(code int, msg string, wrapped error) error {
+	return &ExitError{Code: code, Message: msg, Err: wrapped}
+}
+
+// Returns the exit code carried by err, 0 if err is nil or 1 if err carries no code.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var e *ExitError
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return 1
+}
+
+// Formats error chain, with each ExitError message on its own line.
+func errorChain(err error) string {
+	var lines []string
+	for err != nil {
+		if e, ok := err.(*ExitError); ok {
+			if e.Message != "" {
+				lines = append(lines, e.Message)
+			}
+			err = e.Err
+			continue
+		}
+		lines = append(lines, err.Error())
+		break
+	}
+	return strings.Join(lines, "\n\tcaused by: ")
+}
+
+// Expands a lone error argument in to its error chain.
+func unwrapVars(vars []interface{}) []interface{} {
+	if len(vars) == 1 {
+		if err, ok := vars[0].(error); ok && err != nil {
+			return []interface{}{errorChain(err)}
+		}
+	}
+	return vars
+}
+
+// Returns exit code of the first ExitError found in vars, or 1.
+func varsExitCode(vars []interface{}) int {
+	for _, v := range vars {
+		if err, ok := v.(error); ok {
+			var e *ExitError
+			if errors.As(err, &e) {
+				return e.Code
+			}
+		}
+	}
+	return 1
+}
+
+// Exits with the code carried by err, logging err as an error first, a nil err exits with 0.
+func ExitWith(err error) {
+	if err != nil {
+		Err(err)
+	}
+	Exit(ExitCode(err))
+}
@@ -0,0 +1,49 @@
+package nfo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Rendered global fields, with a leading space, guarded by mutex.
+var global_fields string
+
+// Sets fields, such as app name, version, host or run ID, appended as key=value pairs to each entry written to log files and exported to syslog.
+// Fields are ordered by key, values containing spaces, quotes or '=' are quoted, nil clears all fields.
+func SetGlobalFields(fields map[string]interface{}) {
+	var keys []string
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out strings.Builder
+	for _, k := range keys {
+		v := fmt.Sprintf("%v", fields[k])
+		if v == "" || strings.ContainsAny(v, " \t\"=") {
+			v = strconv.Quote(v)
+		}
+		out.WriteString(" " + k + "=" + v)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	global_fields = out.String()
+}
+
+// Appends global fields to entry, before its trailing newline, mutex must be held by caller.
+func appendFields(entry []byte) []byte {
+	if len(global_fields) == 0 {
+		return entry
+	}
+	n := len(entry)
+	for n > 0 && entry[n-1] == '\n' {
+		n--
+	}
+	out := make([]byte, 0, len(entry)+len(global_fields)+1)
+	out = append(out, entry[:n]...)
+	out = append(out, global_fields...)
+	return append(out, '\n')
+}
@@ -13,10 +13,19 @@ import (
 
 // Gets user input, used during setup and configuration.
 func GetInput(prompt string) string {
+	Pause()
+	defer Resume()
+
+	if piped_stdin {
+		fmt.Print(styledPrompt(prompt))
+		line, _ := readPipedLine()
+		return cleanInput(line)
+	}
+
 	unesc := Defer(getEscape())
 	defer unesc()
 
-	fmt.Printf(prompt)
+	fmt.Print(styledPrompt(prompt))
 
 	terminal.MakeRaw(int(syscall.Stdin))
 
@@ -1,17 +1,73 @@
 package nfo
 
 import (
-	"bufio"
 	"fmt"
+	"golang.org/x/crypto/ssh/terminal"
+	"io"
 	"os"
+	"syscall"
 )
 
+const (
+	enable_virtual_terminal_processing = 0x0004
+	enable_virtual_terminal_input      = 0x0200
+)
+
+var procSetConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+// Adds flags to console mode of handle, returning function to restore the original mode.
+func addConsoleMode(handle syscall.Handle, flags uint32) func() {
+	var mode uint32
+	if err := syscall.GetConsoleMode(handle, &mode); err != nil {
+		return func() {}
+	}
+	procSetConsoleMode.Call(uintptr(handle), uintptr(mode|flags))
+	return func() { procSetConsoleMode.Call(uintptr(handle), uintptr(mode)) }
+}
+
+// Joins console input and output for line editing.
+type console struct {
+	io.Reader
+	io.Writer
+}
+
 // Gets user input, used during setup and configuration.
 func GetInput(prompt string) string {
-	reader := bufio.NewReader(os.Stdin)
+	Pause()
+	defer Resume()
+
+	if piped_stdin {
+		fmt.Print(styledPrompt(prompt))
+		line, _ := readPipedLine()
+		return cleanInput(line)
+	}
+
+	unesc := Defer(getEscape())
+	defer unesc()
+
+	fmt.Print(styledPrompt(prompt))
+
+	terminal.MakeRaw(int(syscall.Stdin))
+
+	// Console must pass escape sequences through, so line editing matches unix terminals.
+	restore_in := addConsoleMode(syscall.Stdin, enable_virtual_terminal_input)
+	defer restore_in()
+	restore_out := addConsoleMode(syscall.Stdout, enable_virtual_terminal_processing)
+	defer restore_out()
 
-	fmt.Printf(prompt)
-	response, _ := reader.ReadString('\n')
+	var (
+		str string
+		err error
+	)
 
-	return cleanInput(response)
+	for {
+		t := terminal.NewTerminal(console{os.Stdin, os.Stdout}, "")
+		str, err = t.ReadLine()
+		if err == io.EOF {
+			signalChan <- syscall.SIGINT
+			continue
+		}
+		break
+	}
+	return cleanInput(str)
 }
@@ -0,0 +1,103 @@
+package nfo
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Time of the last entry written to text output, in unix nanoseconds.
+var last_output int64
+
+// Records output, ending idle time of PleaseWait.
+func markOutput() {
+	atomic.StoreInt64(&last_output, PleaseWait.getClock().Now().UnixNano())
+}
+
+// After PleaseWait has been shown for idle without other output, it switches to a "Still working (12m elapsed)" message redrawn only as it changes.
+// While idle, a heartbeat is written to the INFO log file every heartbeat, so unattended runs leave evidence of liveness, 0 disables either.
+func (L *loading) SetIdle(idle, heartbeat time.Duration) {
+	atomic.StoreInt64(&L.idle_after, int64(idle))
+	atomic.StoreInt64(&L.heartbeat, int64(heartbeat))
+}
+
+// Returns time PleaseWait has been idle, and time it has been shown.
+func (L *loading) idleFor() (idle, elapsed time.Duration) {
+	shown := atomic.LoadInt64(&L.shown)
+	if shown == 0 {
+		return 0, 0
+	}
+	now := L.getClock().Now().UnixNano()
+	since := shown
+	if last := atomic.LoadInt64(&last_output); last > since {
+		since = last
+	}
+	return time.Duration(now - since), time.Duration(now - shown)
+}
+
+// Shows idle message in place of the animation, returning false if PleaseWait is not idle.
+func (L *loading) showIdle(message func() string, last *string) bool {
+	idle_after := time.Duration(atomic.LoadInt64(&L.idle_after))
+	if idle_after <= 0 {
+		return false
+	}
+
+	idle, elapsed := L.idleFor()
+	if idle < idle_after {
+		return false
+	}
+
+	text := fmt.Sprintf("Still working (%s elapsed) ...", elapsedText(elapsed))
+	if text != *last {
+		Flash(text)
+		*last = text
+	}
+
+	if heartbeat := int64(atomic.LoadInt64(&L.heartbeat)); heartbeat > 0 {
+		now := L.getClock().Now().UnixNano()
+		beat := atomic.LoadInt64(&L.beat)
+		// First heartbeat of an idle period is written as the idle message is shown.
+		if beat < now-int64(idle) {
+			beat = now - heartbeat
+		}
+		if now-beat >= heartbeat {
+			atomic.StoreInt64(&L.beat, now)
+			writeHeartbeat(fmt.Sprintf("Still working (%s elapsed): %s", elapsedText(elapsed), message()))
+		}
+	}
+
+	return true
+}
+
+// Formats elapsed time, to the second under a minute and to the minute above, ie.. 45s or 12m.
+func elapsedText(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
+
+// Writes heartbeat to the INFO log file only.
+func writeHeartbeat(text string) {
+	if diskGuarded(INFO) {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	logger := l_map[INFO]
+	if logger.fileout == nil {
+		return
+	}
+
+	var entry []byte
+	genTS(&entry)
+	entry = append(entry, text...)
+	entry = appendFields(append(entry, '\n'))
+
+	if err := writeFile(logger.fileout, entry); err != nil && FatalOnFileError {
+		go Fatal(err)
+	}
+}
@@ -3,6 +3,8 @@ package nfo
 import (
 	"fmt"
 	"github.com/cmcoffee/go-snuglib/xsync"
+	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -10,18 +12,43 @@ import (
 
 func init() {
 	PleaseWait.Set(func() string { return "Please wait ..." }, []string{"[>  ]", "[>> ]", "[>>>]", "[ >>]", "[  >]", "[  <]", "[ <<]", "[<<<]", "[<< ]", "[<  ]"})
+	xsync.RegisterBits(map[uint64]string{
+		loading_show:            "loading_show",
+		transfer_monitor_active: "transfer_monitor_active",
+	})
 }
 
 // PleaseWait is a wait prompt to display between requests.
 var PleaseWait = new(loading)
 
 type loading struct {
-	flag    xsync.BitFlag
-	message func() string
-	anim_1  []string
-	anim_2  []string
-	mutex   sync.Mutex
-	counter int32
+	flag       xsync.BitFlag
+	message    func() string
+	anim_1     []string
+	anim_2     []string
+	mutex      sync.Mutex
+	counter    int32
+	shown      int64 // Time shown, in unix nanoseconds, 0 when hidden.
+	idle_after int64 // Idle time before switching to the idle message, set by SetIdle.
+	heartbeat  int64 // Period of idle heartbeats, set by SetIdle.
+	beat       int64 // Time of last heartbeat, in unix nanoseconds.
+	clock      atomic.Value
+}
+
+// Sets clock the loader, ProgressBar, transfer monitors and PressEnterTimeout are timed by, ie.. an xsync.FakeClock in tests, nil restores the real clock.
+func (L *loading) SetClock(c xsync.Clock) {
+	if c == nil {
+		c = xsync.RealClock
+	}
+	L.clock.Store(&c)
+}
+
+// Returns clock set by SetClock.
+func (L *loading) getClock() xsync.Clock {
+	if c, ok := L.clock.Load().(*xsync.Clock); ok {
+		return *c
+	}
+	return xsync.RealClock
 }
 
 type loading_backup struct {
@@ -71,12 +98,16 @@ func (L *loading) Set(message func() string, loader ...[]string) {
 	count := atomic.AddInt32(&L.counter, 1)
 
 	go func(message func() string, anim_1 []string, anim_2 []string, count int32) {
+		var idle_text string
 		for count == atomic.LoadInt32(&L.counter) {
 			for i, str := range anim_1 {
-				if L.flag.Has(loading_show) && !L.flag.Has(transfer_monitor_active) && count == atomic.LoadInt32(&L.counter) {
-					Flash("%s %s %s", str, message(), anim_2[i])
+				if L.flag.Has(loading_show) && (!L.flag.Has(transfer_monitor_active) || flashLines() > 1) && count == atomic.LoadInt32(&L.counter) {
+					if !L.showIdle(message, &idle_text) {
+						idle_text = ""
+						Flash("%s %s %s", str, message(), anim_2[i])
+					}
 				}
-				time.Sleep(125 * time.Millisecond)
+				<-L.getClock().After(125 * time.Millisecond)
 			}
 		}
 	}(message, anim_1, anim_2, count)
@@ -84,16 +115,43 @@ func (L *loading) Set(message func() string, loader ...[]string) {
 
 // Displays loader. "[>>>] Working, Please wait."
 func (L *loading) Show() {
+	atomic.CompareAndSwapInt64(&L.shown, 0, L.getClock().Now().UnixNano())
 	L.flag.Set(loading_show)
 }
 
 // Hides display loader.
 func (L *loading) Hide() {
 	L.flag.Unset(loading_show)
+	atomic.StoreInt64(&L.shown, 0)
 	time.Sleep(time.Millisecond)
 	Flash("")
 }
 
+// Number of active pauses on animated output.
+var flash_paused int32
+
+// Pauses PleaseWait, ProgressBar and transfer monitor output, clearing the current line or live region.
+// Calls may be nested, each Pause should be matched by a Resume.
+func Pause() {
+	atomic.AddInt32(&flash_paused, 1)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if flush_needed && !piped_stderr {
+		fmt.Fprintf(os.Stderr, "\r%s\r", strings.Repeat(" ", last_flash_len))
+		flush_needed = false
+	}
+	clearRegion()
+}
+
+// Resumes animated output paused by Pause.
+func Resume() {
+	if atomic.AddInt32(&flash_paused, -1) < 0 {
+		atomic.StoreInt32(&flash_paused, 0)
+	}
+}
+
 type progressBar struct {
 	mutex    sync.Mutex
 	cur      int64
@@ -102,6 +160,16 @@ type progressBar struct {
 	name     string
 	anim_len int
 	backup   *loading_backup
+	samples  []progressSample
+}
+
+// Period of Add history used to estimate the rate of a ProgressBar.
+const progress_window = 10 * time.Second
+
+// Progress recorded by Add.
+type progressSample struct {
+	at  time.Time
+	cur int64
 }
 
 var ProgressBar = new(progressBar)
@@ -130,6 +198,7 @@ func (p *progressBar) New(name string, max int) {
 	p.cur = 0
 	p.max = int64(max)
 	p.name = name
+	p.samples = []progressSample{{PleaseWait.getClock().Now(), 0}}
 	p.backup = PleaseWait.Backup()
 	PleaseWait.Set(p.updateMessage, PleaseWait.anim_1)
 	p.anim_len = len(PleaseWait.anim_1)
@@ -138,7 +207,42 @@ func (p *progressBar) New(name string, max int) {
 
 // Adds to progress bar.
 func (p *progressBar) Add(num int) {
-	atomic.StoreInt64(&p.cur, atomic.LoadInt64(&p.cur)+int64(num))
+	cur := atomic.AddInt64(&p.cur, int64(num))
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	now := PleaseWait.getClock().Now()
+	p.samples = append(p.samples, progressSample{now, cur})
+
+	// Keep the newest sample older than the window, so the window is always covered.
+	var i int
+	for i+1 < len(p.samples) && now.Sub(p.samples[i+1].at) >= progress_window {
+		i++
+	}
+	p.samples = p.samples[i:]
+}
+
+// Returns estimated time remaining, based on the rate of Add over recent history, 0 if unknown or complete.
+func (p *progressBar) ETA() time.Duration {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	cur := atomic.LoadInt64(&p.cur)
+	max := atomic.LoadInt64(&p.max)
+
+	if !p.working || len(p.samples) == 0 || cur >= max {
+		return 0
+	}
+
+	first := p.samples[0]
+	elapsed := PleaseWait.getClock().Now().Sub(first.at)
+	if cur <= first.cur || elapsed <= 0 {
+		return 0
+	}
+
+	rate := float64(cur-first.cur) / elapsed.Seconds()
+	return time.Duration(float64(max-cur) / rate * float64(time.Second)).Round(time.Second)
 }
 
 // Complete progress bar, return to loading.
@@ -0,0 +1,45 @@
+package nfo
+
+import (
+	"github.com/cmcoffee/go-snuglib/xsync"
+	"testing"
+	"time"
+)
+
+// Times PleaseWait by a FakeClock for the length of the test.
+func fakeClock(t *testing.T) *xsync.FakeClock {
+	t.Helper()
+	clock := xsync.NewFakeClock(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	PleaseWait.SetClock(clock)
+	t.Cleanup(func() { PleaseWait.SetClock(nil) })
+	return clock
+}
+
+func TestProgressBarETA(t *testing.T) {
+	clock := fakeClock(t)
+
+	ProgressBar.New("items", 100)
+	defer ProgressBar.Done()
+
+	if eta := ProgressBar.ETA(); eta != 0 {
+		t.Errorf("ETA before Add = %s, want 0", eta)
+	}
+
+	// 10 items a second leaves 80 items to go in 8 seconds.
+	for i := 0; i < 2; i++ {
+		clock.Advance(time.Second)
+		ProgressBar.Add(10)
+	}
+	if eta := ProgressBar.ETA(); eta != 8*time.Second {
+		t.Errorf("ETA = %s, want %s", eta, 8*time.Second)
+	}
+
+	// Only the rate over the recent window counts.
+	clock.Advance(progress_window)
+	ProgressBar.Add(40)
+	clock.Advance(progress_window)
+	ProgressBar.Add(20)
+	if eta := ProgressBar.ETA(); eta != progress_window {
+		t.Errorf("ETA after window = %s, want %s", eta, progress_window)
+	}
+}
@@ -0,0 +1,52 @@
+package nfo
+
+import (
+	"github.com/cmcoffee/go-snuglib/iotimeout"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Source of a monitored transfer, read through a timeout reader.
+type timeoutSource struct {
+	ReadSeekCloser
+	reader io.ReadCloser
+	tm     *tmon
+}
+
+// Reads from source, tracking stalls and timeouts on the transfer monitor.
+func (t *timeoutSource) Read(p []byte) (n int, err error) {
+	n, err = t.reader.Read(p)
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt64(&t.tm.idle, 0)
+	if err == iotimeout.ErrTimeout {
+		t.tm.flag.Set(trans_timeout)
+	}
+	return
+}
+
+// Stops the timeout reader and closes source.
+func (t *timeoutSource) Close() error {
+	return t.reader.Close()
+}
+
+// Adds a transfer monitor to source, reads fail with iotimeout.ErrTimeout once no bytes flow for idle_timeout.
+// The transfer is shown as stalled while waiting on source, and the summary line notes when it timed out.
+func MonitoredTimeoutReader(name string, total_size int64, source ReadSeekCloser, idle_timeout time.Duration) ReadSeekCloser {
+	ts := &timeoutSource{
+		ReadSeekCloser: source,
+		reader:         iotimeout.NewReadCloser(source, idle_timeout),
+	}
+
+	tm := TransferMonitor(name, total_size, LeftToRight, ts).(*tmon)
+	tm.timeout = idle_timeout
+	ts.tm = tm
+
+	iotimeout.SetOnWait(ts.reader, func(idle time.Duration) {
+		atomic.StoreInt64(&tm.idle, int64(idle))
+	})
+
+	return tm
+}
@@ -0,0 +1,69 @@
+package nfo
+
+import (
+	"errors"
+	"strings"
+)
+
+var logger_names = map[uint32]string{
+	INFO:   "info",
+	ERROR:  "error",
+	WARN:   "warn",
+	NOTICE: "notice",
+	DEBUG:  "debug",
+	TRACE:  "trace",
+	FATAL:  "fatal",
+	AUX:    "aux",
+	AUX2:   "aux2",
+	AUX3:   "aux3",
+	AUX4:   "aux4",
+}
+
+var errNotAux = errors.New("Only AUX, AUX2, AUX3 and AUX4 loggers may be named.")
+
+// Assigns name to an auxiliary logger, so it may be referenced with LoggerByName, ie.. NameAux(AUX2, "audit").
+func NameAux(flag uint32, name string) error {
+	switch flag {
+	case AUX, AUX2, AUX3, AUX4:
+	default:
+		return errNotAux
+	}
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return errors.New("Logger name cannot be empty.")
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for k, v := range logger_names {
+		if v == name && k != flag {
+			return errors.New("Logger name \"" + name + "\" is already in use.")
+		}
+	}
+	logger_names[flag] = name
+	return nil
+}
+
+// Returns logger flag by name, names are case insensitive.
+func LoggerByName(name string) (flag uint32, found bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for k, v := range logger_names {
+		if v == name {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// Returns name of logger flag.
+func LoggerName(flag uint32) string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return logger_names[flag]
+}
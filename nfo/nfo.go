@@ -67,20 +67,20 @@ var (
 	mutex              sync.Mutex
 	timezone           = time.Local
 	l_map              = map[uint32]*_logger{
-		INFO:        {"", os.Stdout, None, true},
-		AUX:         {"", os.Stdout, None, true},
-		AUX2:        {"", os.Stdout, None, true},
-		AUX3:        {"", os.Stdout, None, true},
-		AUX4:        {"", os.Stdout, None, true},
-		ERROR:       {"[ERROR] ", os.Stdout, None, true},
-		WARN:        {"[WARN] ", os.Stdout, None, true},
-		NOTICE:      {"[NOTICE] ", os.Stdout, None, true},
-		DEBUG:       {"[DEBUG] ", None, None, true},
-		TRACE:       {"[TRACE] ", None, None, true},
-		FATAL:       {"[FATAL] ", os.Stdout, None, true},
-		_flash_txt:  {"", os.Stderr, None, false},
-		_print_txt:  {"", os.Stdout, None, false},
-		_stderr_txt: {"", os.Stderr, None, false},
+		INFO:        {"", os.Stdout, nil, true},
+		AUX:         {"", os.Stdout, nil, true},
+		AUX2:        {"", os.Stdout, nil, true},
+		AUX3:        {"", os.Stdout, nil, true},
+		AUX4:        {"", os.Stdout, nil, true},
+		ERROR:       {"[ERROR] ", os.Stdout, nil, true},
+		WARN:        {"[WARN] ", os.Stdout, nil, true},
+		NOTICE:      {"[NOTICE] ", os.Stdout, nil, true},
+		DEBUG:       {"[DEBUG] ", None, nil, true},
+		TRACE:       {"[TRACE] ", None, nil, true},
+		FATAL:       {"[FATAL] ", os.Stdout, nil, true},
+		_flash_txt:  {"", os.Stderr, nil, false},
+		_print_txt:  {"", os.Stdout, nil, false},
+		_stderr_txt: {"", os.Stderr, nil, false},
 	}
 )
 
@@ -92,12 +92,16 @@ func init() {
 		piped_stderr = true
 	}
 	HideTS()
+	// Log files opened by LogFile keep logging when their manifest can't be updated, so warn rather than fail.
+	wrotate.ErrorHandler = func(name string, err error) {
+		Warn("%s: %s", name, err.Error())
+	}
 }
 
 type _logger struct {
 	prefix  string
 	textout io.Writer
-	fileout io.Writer
+	fileout *fileSink
 	use_ts  bool
 }
 
@@ -138,7 +142,13 @@ func LogFile(filename string, max_size_mb uint, max_rotation uint) (io.Writer, e
 
 	file, err := wrotate.OpenFile(filename, max_size, max_rotation)
 	if err == nil {
-		Defer(file.Close)
+		Defer(func() error {
+			// The file may be wrapped by AddFile, so flush every pending sink rather than looking for it.
+			mutex.Lock()
+			flushBatches()
+			mutex.Unlock()
+			return file.Close()
+		})
 	}
 	return file, err
 }
@@ -168,6 +178,10 @@ func getLogger(flag uint32) *_logger {
 func updateLogger(flag uint32, field uint32, input interface{}) {
 	mutex.Lock()
 	defer mutex.Unlock()
+
+	// Loggers set together share a single file sink.
+	var sink *fileSink
+
 	for k, v := range l_map {
 		if flag&k == k {
 			switch field {
@@ -179,7 +193,10 @@ func updateLogger(flag uint32, field uint32, input interface{}) {
 				}
 			case fileWriter:
 				if x, ok := input.(io.WriteCloser); ok {
-					v.fileout = x
+					if sink == nil {
+						sink = newSink(x)
+					}
+					v.fileout = sink
 				} else {
 					return
 				}
@@ -211,7 +228,10 @@ func GetOutput(flag uint32) io.Writer {
 // Returns log file output.
 func GetFile(flag uint32) io.Writer {
 	t := getLogger(flag)
-	return t.fileout
+	if t.fileout == nil {
+		return None
+	}
+	return t.fileout.w
 }
 
 // Enable Timestamp on output.
@@ -231,10 +251,12 @@ func HideTS(flag ...uint32) {
 }
 
 // Enable a specific logger.
+// As io.Writer requires, w must not retain entries written to it, their buffer is reused by the next entry.
 func SetOutput(flag uint32, w io.Writer) {
 	updateLogger(flag, textWriter, w)
 }
 
+// Sets file a logger writes to, which like SetOutput must not retain entries written to it.
 func SetFile(flag uint32, input io.Writer) {
 	updateLogger(flag, fileWriter, input)
 }
@@ -278,32 +300,54 @@ func UTC() {
 	timezone = time.UTC
 }
 
-// Generate TS Bytes
+// Buffer reused by write2log for each entry, buffers larger than max_entry_buf are not kept.
+// Entries written from it are overwritten by the next, so outputs and files must not retain them.
+var entry_buf []byte
+
+const max_entry_buf = 4096
+
+// Cached timestamp, regenerated only when the second or timezone changes.
+var ts_cache struct {
+	sec  int64
+	loc  *time.Location
+	text []byte
+}
+
+// Generate TS Bytes, mutex must be held by caller.
 func genTS(in *[]byte) {
-	CT := time.Now().In(timezone)
+	now := time.Now()
+
+	if sec := now.Unix(); sec != ts_cache.sec || timezone != ts_cache.loc || ts_cache.text == nil {
+		ts_cache.sec = sec
+		ts_cache.loc = timezone
+		ts_cache.text = formatTS(ts_cache.text[:0], now.In(timezone))
+	}
 
+	*in = append(*in, ts_cache.text...)
+}
+
+// Formats timestamp of CT in to ts.
+func formatTS(ts []byte, CT time.Time) []byte {
 	year, mon, day := CT.Date()
 	hour, min, sec := CT.Clock()
 
-	ts := in
-
-	*ts = append(*ts, '[')
-	Itoa(ts, year, 4)
-	*ts = append(*ts, '/')
-	Itoa(ts, int(mon), 2)
-	*ts = append(*ts, '/')
-	Itoa(ts, day, 2)
-	*ts = append(*ts, ' ')
-	Itoa(ts, hour, 2)
-	*ts = append(*ts, ':')
-	Itoa(ts, min, 2)
-	*ts = append(*ts, ':')
-	Itoa(ts, sec, 2)
-	*ts = append(*ts, ' ')
+	ts = append(ts, '[')
+	Itoa(&ts, year, 4)
+	ts = append(ts, '/')
+	Itoa(&ts, int(mon), 2)
+	ts = append(ts, '/')
+	Itoa(&ts, day, 2)
+	ts = append(ts, ' ')
+	Itoa(&ts, hour, 2)
+	ts = append(ts, ':')
+	Itoa(&ts, min, 2)
+	ts = append(ts, ':')
+	Itoa(&ts, sec, 2)
+	ts = append(ts, ' ')
 
 	zone, _ := CT.Zone()
-	*ts = append(*ts, []byte(zone)[0:]...)
-	*ts = append(*ts, []byte("] ")[0:]...)
+	ts = append(ts, zone...)
+	return append(ts, "] "...)
 }
 
 // Change prefix for specified logger.
@@ -312,8 +356,13 @@ func SetPrefix(logger uint32, prefix_str string) {
 }
 
 // Don't log, write text to standard error which will be overwritten on the next output.
+// When a live region is set by SetFlashLines, text is written to its first line.
 func Flash(vars ...interface{}) {
 	if Animations {
+		if flashLines() > 1 {
+			FlashLine(0, vars...)
+			return
+		}
 		write2log(_flash_txt|_no_logging, vars...)
 	}
 }
@@ -340,9 +389,9 @@ func Log(vars ...interface{}) {
 	write2log(INFO, vars...)
 }
 
-// Log as Error.
+// Log as Error, an error is logged along with the chain of errors it wraps.
 func Err(vars ...interface{}) {
-	write2log(ERROR, vars...)
+	write2log(ERROR, unwrapVars(vars)...)
 }
 
 // Log as Warn.
@@ -375,14 +424,21 @@ func Aux4(vars ...interface{}) {
 	write2log(AUX4, vars...)
 }
 
-// Log as Fatal, then quit.
+// Log as Fatal, then quit, exiting with the code of any ExitError given.
 func Fatal(vars ...interface{}) {
 	if atomic.CompareAndSwapInt32(&fatal_triggered, 0, 1) {
 		// Defer fatal output, so it is the last log entry displayed.
-		write2log(FATAL|_bypass_lock, vars...)
+		write2log(FATAL|_bypass_lock, unwrapVars(vars)...)
+		if fname, err := writeCrashReport(Stringer(unwrapVars(vars)...)); err != nil {
+			write2log(ERROR|_bypass_lock, "Unable to write crash report: %s", err.Error())
+		} else if fname != "" {
+			write2log(INFO|_bypass_lock, "Crash report written to %s.", fname)
+		}
+		code := varsExitCode(vars)
+		errCode = code
 		signalChan <- os.Kill
 		<-exit_lock
-		os.Exit(1)
+		os.Exit(code)
 	} else {
 		// Catch any other fatals and just let them sit.
 		halt := make(chan struct{})
@@ -442,26 +498,58 @@ func write2log(flag uint32, vars ...interface{}) {
 
 	flag = flag &^ _bypass_lock
 
+	var (
+		copies []io.Writer
+		entry  []byte
+	)
+
+	// Copies are written once the mutex is released, so a sink may itself log, ie.. RuleCopy(Writer(AUX)).
+	defer func() {
+		if entry == nil {
+			return
+		}
+		for _, w := range copies {
+			w.Write(entry)
+		}
+	}()
+
 	mutex.Lock()
 	defer mutex.Unlock()
 
+	if flag&_flash_txt != 0 && atomic.LoadInt32(&flash_paused) > 0 {
+		return
+	}
+
+	// Reset buffer.
+	msgBuffer.Reset()
+
+	// Create output string.
+	fprintf(&msgBuffer, vars...)
+
+	// Apply routing rules to log entries.
+	if flag&_no_logging == 0 && flag != FATAL && len(log_rules) > 0 {
+		var drop bool
+		if flag, copies, drop = applyRules(flag, &msgBuffer); drop {
+			return
+		}
+	}
+
+	if flag == ERROR {
+		countError()
+	}
+
 	logger := l_map[flag&^_no_logging]
 
-	var pre []byte
+	// Reuse entry buffer from previous call.
+	pre := entry_buf[:0]
 
 	if flag&_no_logging != _no_logging {
 		if logger.use_ts {
 			genTS(&pre)
 		}
-		pre = append(pre, []byte(logger.prefix)[0:]...)
+		pre = append(pre, logger.prefix...)
 	}
 
-	// Reset buffer.
-	msgBuffer.Reset()
-
-	// Create output string.
-	fprintf(&msgBuffer, vars...)
-
 	// Copy original output for export.
 	msg := msgBuffer.String()
 
@@ -469,6 +557,10 @@ func write2log(flag uint32, vars ...interface{}) {
 	output = append(pre, output[0:]...)
 	bufferLen := len(output)
 
+	if cap(output) <= max_entry_buf {
+		entry_buf = output[:0]
+	}
+
 	if bufferLen > 0 {
 		if output[len(output)-1] != '\n' && flag&_flash_txt != _flash_txt {
 			output = append(output, '\n')
@@ -492,11 +584,20 @@ func write2log(flag uint32, vars ...interface{}) {
 		flush_needed = false
 	}
 
+	// Clear out live region, to be redrawn below the output.
+	var redraw bool
+	if flag&_flash_txt == 0 && !piped_stderr && ((logger.textout == os.Stdout && !piped_stdout) || logger.textout == os.Stderr) {
+		redraw = clearRegion()
+	}
+
 	last_line = bufferLen
 
 	// Flash text handler, make a line of text available to remove remnents of this text.
 	if flag&_flash_txt != 0 {
 		if !piped_stderr {
+			if atomic.LoadInt32(&ascii_output) != 0 {
+				output = []byte(asciiText(string(output)))
+			}
 			width := termWidth()
 			if utf8.RuneCount(output) > width {
 				output = output[0:width]
@@ -510,10 +611,16 @@ func write2log(flag uint32, vars ...interface{}) {
 	}
 
 	io.Copy(logger.textout, bytes.NewReader(output))
+	markOutput()
+	if redraw {
+		drawRegion()
+	}
 	if flag&_no_logging != 0 {
 		return
 	}
 
+	recordEntry(output, logger.use_ts)
+
 	// Preprend timestamp for file.
 	if !logger.use_ts {
 		out_len := len(output)
@@ -523,40 +630,28 @@ func write2log(flag uint32, vars ...interface{}) {
 		output = out
 	}
 
-	// Write to file.
-	_, err := io.Copy(logger.fileout, bytes.NewReader(output))
-	// Launch fatal in a go routine, as the mutex is currently locked.
-	if err != nil && FatalOnFileError {
-		go Fatal(err)
+	if len(global_fields) > 0 {
+		output = appendFields(output)
+		msg = strings.TrimRight(msg, "\n") + global_fields
 	}
 
-	if export_syslog != nil && enabled_exports&flag == flag {
-		switch flag {
-		case INFO:
-			fallthrough
-		case AUX:
-			fallthrough
-		case AUX2:
-			fallthrough
-		case AUX3:
-			fallthrough
-		case AUX4:
-			err = export_syslog.Info(msg)
-		case ERROR:
-			err = export_syslog.Err(msg)
-		case WARN:
-			err = export_syslog.Warning(msg)
-		case FATAL:
-			err = export_syslog.Emerg(msg)
-		case NOTICE:
-			err = export_syslog.Notice(msg)
-		case DEBUG:
-			err = export_syslog.Debug(msg)
-		case TRACE:
-			err = export_syslog.Debug(msg)
-		}
-		if err != nil && FatalOnExportError {
+	var err error
+
+	// Write to file, unless held back by the disk guard.
+	if !diskGuarded(flag) {
+		err = writeFile(logger.fileout, output)
+		// Launch fatal in a go routine, as the mutex is currently locked.
+		if err != nil && FatalOnFileError {
 			go Fatal(err)
 		}
 	}
+
+	// Duplicate entry to sinks of matching rules, which get their own copy as output is reused by the next entry.
+	if len(copies) > 0 {
+		entry = append([]byte(nil), output...)
+	}
+
+	if err = exportSyslog(flag, msg); err != nil && FatalOnExportError {
+		go Fatal(err)
+	}
 }
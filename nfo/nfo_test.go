@@ -0,0 +1,140 @@
+package nfo
+
+import (
+	"io"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// WriteCloser discarding everything written.
+type discardCloser struct{}
+
+func (discardCloser) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (discardCloser) Close() error {
+	return nil
+}
+
+// Sends INFO entries to discarding writers for the length of the benchmark.
+func discardInfo(b *testing.B) {
+	mutex.Lock()
+	logger := l_map[INFO]
+	textout, fileout := logger.textout, logger.fileout
+	mutex.Unlock()
+
+	SetOutput(INFO, io.Discard)
+	SetFile(INFO, discardCloser{})
+
+	b.Cleanup(func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		logger.textout, logger.fileout = textout, fileout
+	})
+}
+
+func BenchmarkGenTS(b *testing.B) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	buf := make([]byte, 0, 64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = buf[:0]
+		genTS(&buf)
+	}
+}
+
+func BenchmarkLog(b *testing.B) {
+	discardInfo(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Log("benchmark entry %d", i)
+	}
+}
+
+func BenchmarkLogRuleCopy(b *testing.B) {
+	discardInfo(b)
+	AddRule(regexp.MustCompile("entry"), RuleCopy(io.Discard))
+	b.Cleanup(ClearRules)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Log("benchmark entry %d", i)
+	}
+}
+
+// Writer retaining every slice written to it.
+type retainer struct {
+	entries [][]byte
+}
+
+func (r *retainer) Write(p []byte) (int, error) {
+	r.entries = append(r.entries, p)
+	return len(p), nil
+}
+
+func TestRuleCopyOwnsEntry(t *testing.T) {
+	mutex.Lock()
+	logger := l_map[INFO]
+	textout, fileout, use_ts := logger.textout, logger.fileout, logger.use_ts
+	logger.textout, logger.fileout, logger.use_ts = io.Discard, &fileSink{discardCloser{}}, true
+	mutex.Unlock()
+	defer func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		logger.textout, logger.fileout, logger.use_ts = textout, fileout, use_ts
+	}()
+
+	var r retainer
+	AddRule(regexp.MustCompile("copied"), RuleCopy(&r))
+	defer ClearRules()
+
+	Log("first copied entry")
+	Log("second copied entry")
+
+	if len(r.entries) != 2 {
+		t.Fatalf("RuleCopy received %d entries, want 2", len(r.entries))
+	}
+	if !regexp.MustCompile("first copied entry\n$").Match(r.entries[0]) {
+		t.Errorf("first entry was overwritten by the next, now %q", r.entries[0])
+	}
+}
+
+func TestRuleCopyToWriter(t *testing.T) {
+	mutex.Lock()
+	info, debug := l_map[INFO], l_map[DEBUG]
+	info_out, info_file := info.textout, info.fileout
+	debug_out, debug_file := debug.textout, debug.fileout
+	info.textout, info.fileout = io.Discard, &fileSink{discardCloser{}}
+	var r retainer
+	debug.textout, debug.fileout = &r, &fileSink{discardCloser{}}
+	mutex.Unlock()
+	defer func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		info.textout, info.fileout = info_out, info_file
+		debug.textout, debug.fileout = debug_out, debug_file
+	}()
+
+	// Copying to a logger of nfo takes the logging lock again, which must not deadlock.
+	AddRule(regexp.MustCompile("^copied"), RuleCopy(Writer(DEBUG)))
+	defer ClearRules()
+
+	done := make(chan struct{})
+	go func() {
+		Log("copied entry")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Log deadlocked copying to Writer")
+	}
+
+	if len(r.entries) != 1 || !regexp.MustCompile("copied entry\n$").Match(r.entries[0]) {
+		t.Errorf("Writer received %q", r.entries)
+	}
+}
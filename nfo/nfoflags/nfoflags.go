@@ -0,0 +1,47 @@
+// Package 'nfoflags' registers standard command line flags for controlling nfo logging through eflag.
+package nfoflags
+
+import (
+	"github.com/cmcoffee/go-snuglib/eflag"
+	"github.com/cmcoffee/go-snuglib/nfo"
+	"os"
+)
+
+// Registers standard logging flags (--debug, --quiet, --log-file, --log-size, --log-count) and applies them to nfo after Parse.
+func StdLoggingFlags(E *eflag.EFlagSet) {
+	var (
+		debug     bool
+		quiet     bool
+		log_file  string
+		log_size  uint
+		log_count uint
+	)
+
+	E.BoolVar(&debug, "debug", "Enable debug output.")
+	E.BoolVar(&quiet, "quiet", "Only display warnings and errors.")
+	E.StringVar(&log_file, "log-file", "", "Write log output to file.")
+	E.Placeholder("log-file", "PATH")
+	E.UintVar(&log_size, "log-size", 10, "Size in MB at which log file is rotated, 0 disables rotation.")
+	E.UintVar(&log_count, "log-count", 5, "Number of rotated log files to keep.")
+
+	E.OnParse(func() error {
+		if quiet {
+			nfo.SetOutput(nfo.INFO|nfo.NOTICE|nfo.AUX|nfo.AUX2|nfo.AUX3|nfo.AUX4, nfo.None)
+		}
+		if debug {
+			nfo.SetOutput(nfo.DEBUG, os.Stdout)
+		}
+		if log_file != "" {
+			f, err := nfo.LogFile(log_file, log_size, log_count)
+			if err != nil {
+				return err
+			}
+			if debug {
+				nfo.SetFile(nfo.STD|nfo.DEBUG, f)
+			} else {
+				nfo.SetFile(nfo.STD, f)
+			}
+		}
+		return nil
+	})
+}
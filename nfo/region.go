@@ -0,0 +1,111 @@
+package nfo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// Live region of Flash output spanning multiple lines.
+var region struct {
+	lines []string // Text of each line.
+	drawn int      // Number of lines currently drawn.
+}
+
+// Sets the number of lines of a live region redrawn in place, allowing PleaseWait, transfer monitors and a status line to be shown at once.
+// Flash draws to the first line, active transfer monitors to the lines following it, and FlashLine to any line.
+// Requires a terminal supporting ANSI cursor movement, lines less than 2 restore single line Flash output.
+func SetFlashLines(lines int) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	clearRegion()
+	if lines < 2 {
+		region.lines = nil
+		return
+	}
+	region.lines = make([]string, lines)
+}
+
+// Returns number of lines of the live region, 0 when disabled.
+func flashLines() int {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return len(region.lines)
+}
+
+// Sets text of line n of the live region set by SetFlashLines, ie.. for a status summary, an empty text removes the line.
+func FlashLine(n int, vars ...interface{}) {
+	if !Animations {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if n < 0 || n >= len(region.lines) {
+		return
+	}
+
+	var buf bytes.Buffer
+	fprintf(&buf, vars...)
+	region.lines[n] = string(bytes.TrimRight(buf.Bytes(), "\n"))
+
+	if atomic.LoadInt32(&flash_paused) > 0 {
+		return
+	}
+
+	drawRegion()
+}
+
+// Redraws live region, mutex must be held by caller.
+func drawRegion() {
+	if piped_stderr || len(region.lines) == 0 {
+		return
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\r")
+	if region.drawn > 1 {
+		fmt.Fprintf(&buf, "\x1b[%dA", region.drawn-1)
+	}
+	buf.WriteString("\x1b[J")
+
+	// Lines after the last line with text are not drawn.
+	var height int
+	for i, line := range region.lines {
+		if line != "" {
+			height = i + 1
+		}
+	}
+
+	width := termWidth()
+	for i := 0; i < height; i++ {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		line := []rune(asciiText(region.lines[i]))
+		if width > 0 && len(line) > width {
+			line = line[:width]
+		}
+		buf.WriteString(string(line))
+	}
+
+	region.drawn = height
+	os.Stderr.Write(buf.Bytes())
+}
+
+// Clears drawn live region, returning true if it was drawn, mutex must be held by caller.
+func clearRegion() bool {
+	if region.drawn == 0 || piped_stderr {
+		return false
+	}
+	if region.drawn > 1 {
+		fmt.Fprintf(os.Stderr, "\r\x1b[%dA\x1b[J", region.drawn-1)
+	} else {
+		fmt.Fprintf(os.Stderr, "\r\x1b[J")
+	}
+	region.drawn = 0
+	return true
+}
@@ -0,0 +1,67 @@
+package nfo
+
+import (
+	"io"
+	"os"
+	"os/signal"
+)
+
+var reopenChan chan os.Signal
+
+// Reopens log files moved, removed or truncated by external rotation, ie.. logrotate with or without copytruncate.
+func ReopenFiles() (err error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	for _, s := range fileSinks() {
+		flushWriter(s)
+		if e := reopen(s.w); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// Reopens log file w, along with any files it duplicates writes to.
+func reopen(w io.Writer) error {
+	switch w := w.(type) {
+	case *teeWriter:
+		w.mutex.Lock()
+		primary, secondary := w.primary, w.secondary
+		w.mutex.Unlock()
+		if err := reopen(primary); err != nil {
+			return err
+		}
+		if secondary != nil {
+			return reopen(secondary)
+		}
+	case interface{ Reopen() error }:
+		return w.Reopen()
+	}
+	return nil
+}
+
+// Calls ReopenFiles on receiving any of sig, ie.. syscall.SIGUSR1 sent by logrotate's postrotate script.
+// The signals should not also be passed to SetSignals.
+func ReopenOnSignal(sig ...os.Signal) {
+	if len(sig) == 0 {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if reopenChan == nil {
+		reopenChan = make(chan os.Signal, 1)
+		go func() {
+			for range reopenChan {
+				if err := ReopenFiles(); err != nil {
+					Err(err)
+				}
+			}
+		}()
+	}
+
+	signal.Stop(reopenChan)
+	signal.Notify(reopenChan, sig...)
+}
@@ -0,0 +1,124 @@
+package nfo
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Log entry being evaluated against routing rules.
+type ruleEntry struct {
+	flag   uint32
+	tags   []string
+	copies []io.Writer
+	drop   bool
+}
+
+// RuleAction changes how a log entry matching a rule is handled.
+type RuleAction func(e *ruleEntry)
+
+type logRule struct {
+	match   *regexp.Regexp
+	actions []RuleAction
+}
+
+var log_rules []logRule
+
+// Adds rule applying actions to log entries matching match, rules are applied in the order added.
+func AddRule(match *regexp.Regexp, actions ...RuleAction) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	log_rules = append(log_rules, logRule{match, actions})
+}
+
+// Removes all routing rules.
+func ClearRules() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	log_rules = nil
+}
+
+// Logs matching entries to logger flag instead, raising or lowering its level.
+func RuleLevel(flag uint32) RuleAction {
+	return func(e *ruleEntry) {
+		if _, ok := l_map[flag]; ok && flag != FATAL {
+			e.flag = flag
+		}
+	}
+}
+
+// Drops matching entries.
+func RuleDrop() RuleAction {
+	return func(e *ruleEntry) {
+		e.drop = true
+	}
+}
+
+// Tags matching entries, ie.. "[tag] message".
+func RuleTag(tag string) RuleAction {
+	return func(e *ruleEntry) {
+		e.tags = append(e.tags, tag)
+	}
+}
+
+// Duplicates matching entries to w, in addition to their logger's output, each entry is a copy w may retain.
+// Entries are written to w after logging releases its lock, so w may log through nfo, ie.. Writer, provided the entries it logs do not match the same rule.
+func RuleCopy(w io.Writer) RuleAction {
+	return func(e *ruleEntry) {
+		e.copies = append(e.copies, w)
+	}
+}
+
+// Applies routing rules to message, mutex must be held by caller.
+func applyRules(flag uint32, msg *bytes.Buffer) (uint32, []io.Writer, bool) {
+	e := ruleEntry{flag: flag}
+	text := msg.String()
+
+	for _, r := range log_rules {
+		if !r.match.MatchString(text) {
+			continue
+		}
+		for _, action := range r.actions {
+			action(&e)
+		}
+		if e.drop {
+			return flag, nil, true
+		}
+	}
+
+	if len(e.tags) > 0 {
+		msg.Reset()
+		for _, t := range e.tags {
+			msg.WriteString("[" + t + "] ")
+		}
+		msg.WriteString(text)
+	}
+
+	return e.flag, e.copies, false
+}
+
+// Writer adapter, logging each line written at the level of its logger.
+type logWriter struct {
+	flag uint32
+	buf  []byte
+}
+
+// Returns io.Writer logging each line written to logger flag, for funneling output of other packages through nfo.
+func Writer(flag uint32) io.Writer {
+	return &logWriter{flag: flag}
+}
+
+func (w *logWriter) Write(p []byte) (n int, err error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimRight(string(w.buf[:i]), "\r")
+		w.buf = w.buf[i+1:]
+		write2log(w.flag, line)
+	}
+	return len(p), nil
+}
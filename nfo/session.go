@@ -0,0 +1,75 @@
+package nfo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Returns all unique file outputs currently assigned to loggers, mutex must be held by caller.
+func fileSinks() (sinks []*fileSink) {
+	for _, v := range l_map {
+		if v.fileout == nil {
+			continue
+		}
+		var found bool
+		for _, s := range sinks {
+			if s == v.fileout {
+				found = true
+				break
+			}
+		}
+		if !found {
+			sinks = append(sinks, v.fileout)
+		}
+	}
+	return
+}
+
+// Writes timestamped lines directly to all log files, bypassing text output.
+func write2files(lines ...string) {
+	if diskGuarded(INFO) {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	var (
+		ts  []byte
+		buf bytes.Buffer
+	)
+
+	genTS(&ts)
+
+	for _, line := range lines {
+		buf.Write(ts)
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+
+	for _, f := range fileSinks() {
+		if err := writeFile(f, buf.Bytes()); err != nil && FatalOnFileError {
+			go Fatal(err)
+		}
+	}
+}
+
+// Writes a banner to log files marking the start of a run, a closing banner with duration and exit code is written on exit.
+// Should be called after log files are assigned, so the closing banner is written before the files are closed.
+func SessionStart(appName, version string, args []string) {
+	start := time.Now()
+	host, _ := os.Hostname()
+
+	write2files(
+		fmt.Sprintf("===== Session Start: %s %s =====", appName, version),
+		fmt.Sprintf("Host: %s, PID: %d", host, os.Getpid()),
+		fmt.Sprintf("Arguments: %s", strings.Join(args, " ")),
+	)
+
+	Defer(func() {
+		write2files(fmt.Sprintf("===== Session End: %s %s, Duration: %s, Exit Code: %d =====", appName, version, time.Since(start).Round(time.Millisecond), errCode))
+	})
+}
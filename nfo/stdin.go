@@ -0,0 +1,61 @@
+package nfo
+
+import (
+	"bufio"
+	"golang.org/x/crypto/ssh/terminal"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+)
+
+var (
+	piped_stdin  bool
+	stdin_eof    int32
+	stdin_reader = bufio.NewReader(os.Stdin)
+)
+
+func init() {
+	if !terminal.IsTerminal(int(syscall.Stdin)) {
+		piped_stdin = true
+	}
+}
+
+// Returns true if standard input is not a terminal, such as when input is piped or redirected.
+// Prompts read answers from standard input line by line rather than interacting with the terminal.
+func StdinIsPipe() bool {
+	return piped_stdin
+}
+
+// Returns true once piped standard input has been fully consumed.
+func stdinDone() bool {
+	return piped_stdin && atomic.LoadInt32(&stdin_eof) == 1
+}
+
+// Reads a line from piped standard input, with line endings removed.
+func readPipedLine() (line string, ok bool) {
+	line, err := stdin_reader.ReadString('\n')
+	if err != nil {
+		atomic.StoreInt32(&stdin_eof, 1)
+		if line == "" {
+			return "", false
+		}
+	}
+	return strings.TrimRight(line, "\r\n"), true
+}
+
+// Returns lines read from standard input, with line endings removed, channel is closed on EOF.
+func ReadLines() <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for {
+			line, ok := readPipedLine()
+			if !ok {
+				return
+			}
+			lines <- line
+		}
+	}()
+	return lines
+}
@@ -0,0 +1,74 @@
+package nfo
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var summary struct {
+	enabled int32
+	start   time.Time
+	files   int64
+	bytes   int64
+	errors  int64
+}
+
+// Disables progress and transfer displays, logging a summary of the run (files, bytes, duration, average rate and errors) at INFO on exit instead.
+// Intended for scheduled non-interactive runs, the summary counts transfers closed and errors logged from when it was enabled.
+func SetQuietSummary(enabled bool) {
+	if !enabled {
+		atomic.StoreInt32(&summary.enabled, 0)
+		Animations = true
+		return
+	}
+
+	mutex.Lock()
+	summary.start = time.Now()
+	mutex.Unlock()
+
+	atomic.StoreInt64(&summary.files, 0)
+	atomic.StoreInt64(&summary.bytes, 0)
+	atomic.StoreInt64(&summary.errors, 0)
+	Animations = false
+	atomic.StoreInt32(&summary.enabled, 1)
+}
+
+// Adds closed transfer to summary, returning false when quiet summary mode is not enabled.
+func quietSummary(tm *tmon) bool {
+	if atomic.LoadInt32(&summary.enabled) == 0 {
+		return false
+	}
+	atomic.AddInt64(&summary.files, 1)
+	atomic.AddInt64(&summary.bytes, atomic.LoadInt64(&tm.transferred)-tm.offset)
+	if tm.flag.Has(trans_error | trans_timeout) {
+		atomic.AddInt64(&summary.errors, 1)
+	}
+	return true
+}
+
+// Counts logged error for summary.
+func countError() {
+	if atomic.LoadInt32(&summary.enabled) != 0 {
+		atomic.AddInt64(&summary.errors, 1)
+	}
+}
+
+// Logs summary of run when quiet summary mode is enabled.
+func logSummary() {
+	if atomic.LoadInt32(&summary.enabled) == 0 {
+		return
+	}
+
+	mutex.Lock()
+	elapsed := time.Since(summary.start)
+	mutex.Unlock()
+
+	bytes := atomic.LoadInt64(&summary.bytes)
+
+	since := elapsed.Seconds()
+	if since < 0.1 {
+		since = 0.1
+	}
+
+	write2log(INFO|_bypass_lock, "Summary: %d files, %s in %s (%s), %d errors.", atomic.LoadInt64(&summary.files), HumanSize(bytes), elapsed.Round(time.Second), humanRate(float64(bytes)*8/since), atomic.LoadInt64(&summary.errors))
+}
@@ -14,6 +14,38 @@ type SyslogWriter interface {
 	Warning(string) error
 }
 
+// Syslog severity entries of a logger are sent with.
+type Severity int
+
+// Syslog severities, in the order of log/syslog.
+const (
+	SyslogEmerg Severity = iota
+	SyslogAlert
+	SyslogCrit
+	SyslogErr
+	SyslogWarning
+	SyslogNotice
+	SyslogInfo
+	SyslogDebug
+)
+
+var (
+	syslog_severity = map[uint32]Severity{
+		INFO:   SyslogInfo,
+		ERROR:  SyslogErr,
+		WARN:   SyslogWarning,
+		NOTICE: SyslogNotice,
+		DEBUG:  SyslogDebug,
+		TRACE:  SyslogDebug,
+		FATAL:  SyslogEmerg,
+		AUX:    SyslogInfo,
+		AUX2:   SyslogInfo,
+		AUX3:   SyslogInfo,
+		AUX4:   SyslogInfo,
+	}
+	syslog_writers = make(map[uint32]SyslogWriter)
+)
+
 // Send messages to syslog
 func HookSyslog(syslog_writer SyslogWriter) {
 	mutex.Lock()
@@ -27,3 +59,69 @@ func UnhookSyslog() {
 	defer mutex.Unlock()
 	export_syslog = nil
 }
+
+// Sets syslog severity of entries of the loggers specified, ie.. SyslogSeverity(FATAL, SyslogCrit).
+func SyslogSeverity(flag uint32, severity Severity) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for k := range syslog_severity {
+		if flag&k == k {
+			syslog_severity[k] = severity
+		}
+	}
+}
+
+// Sends entries of the loggers specified to syslog_writer rather than the writer given to HookSyslog.
+// Allows selecting a facility per logger, ie.. a writer from syslog.New(syslog.LOG_LOCAL0, tag), a nil syslog_writer reverts to HookSyslog.
+func SyslogFacility(flag uint32, syslog_writer SyslogWriter) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for k := range syslog_severity {
+		if flag&k == k {
+			if syslog_writer == nil {
+				delete(syslog_writers, k)
+			} else {
+				syslog_writers[k] = syslog_writer
+			}
+		}
+	}
+}
+
+// Exports msg of logger flag to syslog, mutex must be held by caller.
+func exportSyslog(flag uint32, msg string) error {
+	if enabled_exports&flag != flag {
+		return nil
+	}
+
+	w, ok := syslog_writers[flag]
+	if !ok {
+		w = export_syslog
+	}
+	if w == nil {
+		return nil
+	}
+
+	severity, ok := syslog_severity[flag]
+	if !ok {
+		return nil
+	}
+
+	switch severity {
+	case SyslogEmerg:
+		return w.Emerg(msg)
+	case SyslogAlert:
+		return w.Alert(msg)
+	case SyslogCrit:
+		return w.Crit(msg)
+	case SyslogErr:
+		return w.Err(msg)
+	case SyslogWarning:
+		return w.Warning(msg)
+	case SyslogNotice:
+		return w.Notice(msg)
+	case SyslogInfo:
+		return w.Info(msg)
+	default:
+		return w.Debug(msg)
+	}
+}
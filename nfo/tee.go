@@ -0,0 +1,79 @@
+package nfo
+
+import (
+	"io"
+	"sync"
+)
+
+type teeWriter struct {
+	mutex     sync.Mutex
+	primary   io.Writer
+	secondary io.Writer
+	drop      bool
+}
+
+// Tee duplicates writes to primary and secondary, errors from primary are always returned.
+// If dropOnError is true, a failing secondary is detached with a warning rather than returning an error.
+// Closing the returned writer does not close primary or secondary.
+func Tee(primary, secondary io.Writer, dropOnError bool) io.WriteCloser {
+	return &teeWriter{
+		primary:   primary,
+		secondary: secondary,
+		drop:      dropOnError,
+	}
+}
+
+func (t *teeWriter) Write(p []byte) (n int, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	n, err = t.primary.Write(p)
+	if err != nil {
+		return
+	}
+
+	if t.secondary == nil {
+		return
+	}
+
+	if _, e := t.secondary.Write(p); e != nil {
+		if !t.drop {
+			return n, e
+		}
+		t.secondary = nil
+		// Launch warning in a go routine, as the logging mutex may be locked.
+		go Warn("Secondary log output failed and has been detached: %s", e.Error())
+	}
+	return
+}
+
+func (t *teeWriter) Close() error {
+	return nil
+}
+
+// Adds an additional log file to the loggers specified, a failing additional file is detached rather than triggering FatalOnFileError.
+func AddFile(flag uint32, w io.Writer) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	// Loggers sharing a file share a single tee.
+	tees := make(map[*fileSink]*fileSink)
+	var sink *fileSink
+
+	for k, v := range l_map {
+		if flag&k != k || k&(_flash_txt|_print_txt|_stderr_txt) != 0 {
+			continue
+		}
+		if v.fileout == nil {
+			if sink == nil {
+				sink = newSink(w)
+			}
+			v.fileout = sink
+			continue
+		}
+		if _, ok := tees[v.fileout]; !ok {
+			tees[v.fileout] = &fileSink{Tee(v.fileout.w, w, true)}
+		}
+		v.fileout = tees[v.fileout]
+	}
+}
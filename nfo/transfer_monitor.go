@@ -4,6 +4,8 @@ import (
 	"fmt"
 	. "github.com/cmcoffee/go-snuglib/xsync"
 	"golang.org/x/crypto/ssh/terminal"
+	"hash"
+	"io"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -42,8 +44,19 @@ const (
 	trans_closed
 	trans_complete
 	trans_error
+	trans_timeout
 )
 
+func init() {
+	RegisterBits(map[uint64]string{
+		trans_active:   "trans_active",
+		trans_closed:   "trans_closed",
+		trans_complete: "trans_complete",
+		trans_error:    "trans_error",
+		trans_timeout:  "trans_timeout",
+	})
+}
+
 type readSeekCounter struct {
 	counter func(int)
 	ReadSeekCloser
@@ -110,6 +123,8 @@ func TransferMonitor(name string, total_size int64, flag int, source ReadSeekClo
 
 	b_flag.Set(trans_active)
 
+	clock := PleaseWait.getClock()
+
 	tm := &tmon{
 		flag:        b_flag,
 		name:        name,
@@ -119,8 +134,10 @@ func TransferMonitor(name string, total_size int64, flag int, source ReadSeekClo
 		transferred: 0,
 		offset:      0,
 		rate:        "0.0bps",
-		start_time:  time.Now(),
+		start_time:  clock.Now(),
+		clock:       clock,
 		source:      source,
+		hash:        checkpointHash(),
 	}
 
 	var spin_index int
@@ -142,6 +159,9 @@ func TransferMonitor(name string, total_size int64, flag int, source ReadSeekClo
 		transferDisplay.display = 1
 
 		go func() {
+			// Lines of the live region drawn by transfers.
+			var drawn int
+
 			for {
 				transferDisplay.update_lock.Lock()
 
@@ -159,11 +179,30 @@ func TransferMonitor(name string, total_size int64, flag int, source ReadSeekClo
 				if len(transferDisplay.monitors) == 0 {
 					PleaseWait.flag.Unset(transfer_monitor_active)
 					transferDisplay.update_lock.Unlock()
+					for i := 1; i <= drawn; i++ {
+						FlashLine(i, "")
+					}
 					return
 				}
 
 				transferDisplay.update_lock.Unlock()
 
+				// Display transfers on lines of the live region following Flash, oldest first.
+				if lines := flashLines(); lines > 1 {
+					spin := spinner()
+					var n int
+					for i := len(monitors) - 1; i >= 0 && n+1 < lines; i-- {
+						n++
+						FlashLine(n, "[%s] %s", spin, monitors[i].showTransfer(false))
+					}
+					for i := n + 1; i <= drawn; i++ {
+						FlashLine(i, "")
+					}
+					drawn = n
+					time.Sleep(time.Millisecond * 200)
+					continue
+				}
+
 				// Display transfers.
 				for _, v := range monitors {
 					for i := 0; i < 10; i++ {
@@ -186,6 +225,7 @@ func TransferMonitor(name string, total_size int64, flag int, source ReadSeekClo
 // Wrapper Seeker
 func (tm *tmon) Seek(offset int64, whence int) (int64, error) {
 	o, err := tm.source.Seek(offset, whence)
+	tm.seekCheckpoint(tm.transferred, o)
 	tm.transferred = o
 	tm.offset = o
 	return o, err
@@ -195,11 +235,17 @@ func (tm *tmon) Seek(offset int64, whence int) (int64, error) {
 func (tm *tmon) Read(p []byte) (n int, err error) {
 	n, err = tm.source.Read(p)
 	atomic.StoreInt64(&tm.transferred, atomic.LoadInt64(&tm.transferred)+int64(n))
+	if n > 0 {
+		tm.checkpoint(p[:n])
+	}
 	if err != nil {
 		if tm.flag.Has(trans_closed) {
 			return
 		}
-		tm.flag.Set(trans_closed | trans_error)
+		tm.flag.Set(trans_closed)
+		if err != io.EOF {
+			tm.flag.Set(trans_error)
+		}
 		if tm.transferred == 0 {
 			return
 		}
@@ -210,8 +256,12 @@ func (tm *tmon) Read(p []byte) (n int, err error) {
 // Close out speicfic transfer monitor
 func (tm *tmon) Close() error {
 	tm.flag.Set(trans_closed)
+	tm.closeCheckpoint()
+	if quietSummary(tm) {
+		return tm.source.Close()
+	}
 	if !tm.flag.Has(NoRate) {
-		if tm.transferred > 0 || tm.total_size == 0 {
+		if tm.transferred > 0 || tm.total_size == 0 || tm.flag.Has(trans_timeout) {
 			Log(tm.showTransfer(true))
 		}
 	}
@@ -239,10 +289,27 @@ type tmon struct {
 	chunk_size  int64
 	start_time  time.Time
 	source      ReadSeekCloser
+	idle        int64         // Nanoseconds spent waiting on source, set by MonitoredTimeoutReader.
+	timeout     time.Duration // Idle timeout of source, set by MonitoredTimeoutReader.
+	hash        hash.Hash     // Hash of bytes read, when checkpoints are enabled.
+	hash_lock   sync.Mutex
+	saved       time.Time // Time of last checkpoint.
+	clock       Clock     // Clock of PleaseWait when the transfer began.
 }
 
 // Outputs progress of TMonitor.
 func (t *tmon) showTransfer(summary bool) string {
+	if summary && t.flag.Has(trans_timeout) {
+		return fmt.Sprintf("%s (timed out after %s idle)", t.showProgress(summary), t.timeout)
+	}
+	if idle := time.Duration(atomic.LoadInt64(&t.idle)); !summary && idle > 0 {
+		return fmt.Sprintf("%s (stalled %s)", t.showProgress(summary), idle.Round(time.Second))
+	}
+	return t.showProgress(summary)
+}
+
+// Outputs progress bar or rate of TMonitor.
+func (t *tmon) showProgress(summary bool) string {
 	transferred := atomic.LoadInt64(&t.transferred)
 	rate := t.showRate()
 
@@ -275,29 +342,15 @@ func (t *tmon) showRate() (rate string) {
 		return t.rate
 	}
 
-	since := time.Since(t.start_time).Seconds()
+	since := t.clock.Now().Sub(t.start_time).Seconds()
 	if since < 0.1 {
 		since = 0.1
 	}
 
 	sz := float64(transferred-t.offset) * 8 / since
 
-	names := []string{
-		"bps",
-		"kbps",
-		"mbps",
-		"gbps",
-	}
-
-	suffix := 0
-
-	for sz >= 1000 && suffix < len(names)-1 {
-		sz = sz / 1000
-		suffix++
-	}
-
 	if sz != 0.0 {
-		rate = fmt.Sprintf("%.1f%s", sz, names[suffix])
+		rate = humanRate(sz)
 	} else {
 		if t.flag.Has(trans_active) {
 			rate = "0.0bps"
@@ -319,6 +372,25 @@ func (t *tmon) showRate() (rate string) {
 	}
 }
 
+// Provides human readable rate of bits per second.
+func humanRate(sz float64) string {
+	names := []string{
+		"bps",
+		"kbps",
+		"mbps",
+		"gbps",
+	}
+
+	suffix := 0
+
+	for sz >= 1000 && suffix < len(names)-1 {
+		sz = sz / 1000
+		suffix++
+	}
+
+	return fmt.Sprintf("%.1f%s", sz, names[suffix])
+}
+
 // Draws a progress bar using sz as the size.
 func DrawProgressBar(sz int, current, max int64, text string) string {
 	var num int
@@ -331,9 +403,11 @@ func DrawProgressBar(sz int, current, max int64, text string) string {
 	display := make([]rune, sz)
 	x := num * sz / 100
 
+	glyph := progressGlyph()
+
 	for n := range display {
 		if n < x {
-			display[n] = '░'
+			display[n] = glyph
 		} else {
 			display[n] = '.'
 		}
@@ -0,0 +1,30 @@
+package nfo
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransferRate(t *testing.T) {
+	clock := fakeClock(t)
+
+	tm := &tmon{flag: trans_active, rate: "0.0bps", start_time: clock.Now(), clock: clock}
+	if rate := tm.showRate(); rate != "0.0bps" {
+		t.Errorf("showRate before transfer = %q", rate)
+	}
+
+	// 2.5MB over 4 seconds is 5mbps.
+	tm.transferred = 2500000
+	clock.Advance(4 * time.Second)
+	if rate := strings.TrimSpace(tm.showRate()); rate != "5.0mbps" {
+		t.Errorf("showRate = %q, want %q", rate, "5.0mbps")
+	}
+}
+
+func TestTransferFlagString(t *testing.T) {
+	tm := &tmon{flag: trans_active | trans_closed}
+	if s := tm.flag.String(); s != "trans_active|trans_closed" {
+		t.Errorf("flag.String() = %q", s)
+	}
+}
@@ -1,15 +1,38 @@
 package swapreader
 
 import (
+	"errors"
 	"io"
 )
 
+var (
+	ErrUnsupported = errors.New("Operation not supported by current source.")
+	ErrOffset      = errors.New("Offset out of range.")
+)
+
 // Swap Reader allows for swapping the io.Reader backed []bytes
 type Reader struct {
 	from_reader    bool
 	reader         io.Reader
 	decoder_bytes  []byte
 	decoder_copied int
+	fallback       func(err error) (io.Reader, error)
+	read           int64
+	eof            bool
+}
+
+// SourceError is passed to the fallback set with SetFallback, Offset is the number of bytes read since SetFallback.
+type SourceError struct {
+	Offset int64
+	Err    error
+}
+
+func (e *SourceError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
 }
 
 // Set []byte for reader
@@ -17,18 +40,62 @@ func (r *Reader) SetBytes(in []byte) {
 	r.from_reader = false
 	r.decoder_bytes = in
 	r.decoder_copied = 0
+	r.fallback = nil
+	r.read = 0
+	r.eof = false
 }
 
 // Set Reader to Reader
 func (r *Reader) SetReader(in io.Reader) {
 	r.from_reader = true
 	r.reader = in
+	r.fallback = nil
+	r.read = 0
+	r.eof = false
+}
+
+// Sets primary as the source of reader, a read error other than io.EOF calls fallback for a replacement source.
+// The error passed to fallback is a *SourceError holding the offset to resume from, an error returned by fallback is returned by Read.
+// A fallback returning a nil reader without an error leaves the failed source in place, and Read returns its error.
+func (r *Reader) SetFallback(primary io.Reader, fallback func(err error) (io.Reader, error)) {
+	r.SetReader(primary)
+	r.fallback = fallback
+}
+
+// Reads from source set with SetReader or SetFallback, swapping in replacement sources on error.
+func (r *Reader) readSource(p []byte) (n int, err error) {
+	for {
+		n, err = r.reader.Read(p)
+		r.read += int64(n)
+		if err == io.EOF {
+			r.eof = true
+		}
+		if err == nil || err == io.EOF || r.fallback == nil {
+			return n, err
+		}
+		next, f_err := r.fallback(&SourceError{r.read, err})
+		if f_err != nil {
+			return n, f_err
+		}
+		if next == nil {
+			return n, err
+		}
+		r.reader = next
+		if n > 0 {
+			return n, nil
+		}
+	}
 }
 
 // swap_reader Read function.
 func (r *Reader) Read(p []byte) (n int, err error) {
 
 	if !r.from_reader {
+		// Seek may leave the position past the end.
+		if r.decoder_copied >= len(r.decoder_bytes) {
+			return 0, io.EOF
+		}
+
 		buffer_len := len(r.decoder_bytes) - r.decoder_copied
 
 		if len(p) <= buffer_len {
@@ -49,9 +116,94 @@ func (r *Reader) Read(p []byte) (n int, err error) {
 			err = io.EOF
 		}
 
+		r.read += int64(buffer_len - transferred)
 		return buffer_len - transferred, err
 	} else {
-		return r.Read(p)
+		return r.readSource(p)
 	}
 
 }
+
+// Reads len(p) bytes at offset, without changing the position of Read.
+// A source set by SetReader must implement io.ReaderAt.
+func (r *Reader) ReadAt(p []byte, off int64) (n int, err error) {
+	if r.from_reader {
+		if ra, ok := r.reader.(io.ReaderAt); ok {
+			return ra.ReadAt(p, off)
+		}
+		return 0, ErrUnsupported
+	}
+
+	if off < 0 {
+		return 0, ErrOffset
+	}
+	if off >= int64(len(r.decoder_bytes)) {
+		return 0, io.EOF
+	}
+
+	n = copy(p, r.decoder_bytes[off:])
+	if n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Sets position of the next Read, interpreted according to whence as with io.Seeker.
+// Seeking past the end is allowed, the next Read returns io.EOF. A source set by SetReader must implement io.Seeker.
+func (r *Reader) Seek(offset int64, whence int) (int64, error) {
+	if r.from_reader {
+		if s, ok := r.reader.(io.Seeker); ok {
+			pos, err := s.Seek(offset, whence)
+			if err == nil {
+				r.eof = false
+			}
+			return pos, err
+		}
+		return 0, ErrUnsupported
+	}
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += int64(r.decoder_copied)
+	case io.SeekEnd:
+		offset += int64(len(r.decoder_bytes))
+	default:
+		return 0, ErrOffset
+	}
+
+	if offset < 0 {
+		return 0, ErrOffset
+	}
+
+	r.decoder_copied = int(offset)
+	return offset, nil
+}
+
+// Returns number of bytes returned by Read since the source was last set.
+func (r *Reader) BytesRead() int64 {
+	return r.read
+}
+
+// Returns number of bytes left to read, found is false when the length of a source set by SetReader is unknown.
+// Sources implementing Len() int, such as bytes.Reader and strings.Reader, report their length.
+func (r *Reader) Remaining() (remaining int64, found bool) {
+	if !r.from_reader {
+		if r.decoder_copied >= len(r.decoder_bytes) {
+			return 0, true
+		}
+		return int64(len(r.decoder_bytes) - r.decoder_copied), true
+	}
+	if l, ok := r.reader.(interface{ Len() int }); ok {
+		return int64(l.Len()), true
+	}
+	return -1, false
+}
+
+// Reports whether all content has been read, a source set by SetReader is at EOF once it has returned io.EOF.
+func (r *Reader) AtEOF() bool {
+	if !r.from_reader {
+		return r.decoder_copied >= len(r.decoder_bytes)
+	}
+	return r.eof
+}
@@ -0,0 +1,133 @@
+package wrotate
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Manifest entry for a rotated log file.
+type manifestEntry struct {
+	name  string
+	size  int64
+	sum   string
+	start time.Time
+	end   time.Time
+}
+
+// Returns the manifest filename for the log file.
+func manifestFile(name string) string {
+	return name + ".manifest"
+}
+
+// Reads existing manifest, missing or malformed entries are skipped.
+func readManifest(name string) (entries []manifestEntry) {
+	f, err := os.Open(manifestFile(name))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 5 {
+			continue
+		}
+		var (
+			e   manifestEntry
+			err error
+		)
+		e.name = fields[0]
+		if e.size, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+			continue
+		}
+		e.sum = fields[2]
+		if e.start, err = time.Parse(time.RFC3339, fields[3]); err != nil {
+			continue
+		}
+		if e.end, err = time.Parse(time.RFC3339, fields[4]); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return
+}
+
+// Writes manifest to a temporary file, then moves it in to place.
+func writeManifest(name string, entries []manifestEntry) (err error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "# name\tsize\tsha256\tstart\tend\n")
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "%s\t%d\t%s\t%s\t%s\n", e.name, e.size, e.sum, e.start.Format(time.RFC3339), e.end.Format(time.RFC3339))
+	}
+
+	tmp := manifestFile(name) + ".tmp"
+	if err = os.WriteFile(tmp, buf.Bytes(), 0666); err != nil {
+		return err
+	}
+	return os.Rename(tmp, manifestFile(name))
+}
+
+// Returns size and sha256 sum of file.
+func hashFile(name string) (size int64, sum string, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if size, err = io.Copy(h, f); err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Returns the start time of the current log file, which is the end of the last rotation recorded.
+func lastRotation(name string) time.Time {
+	for _, e := range readManifest(name) {
+		if e.name == fmt.Sprintf("%s.1", filepath.Base(name)) {
+			return e.end
+		}
+	}
+	return time.Now()
+}
+
+// Records the newly rotated log file, shifting existing manifest entries along with their files.
+func (R *rotaFile) updateManifest(fpath, fname string) (err error) {
+	now := time.Now()
+	start := R.started
+	R.started = now
+
+	size, sum, err := hashFile(fmt.Sprintf("%s%s.1", fpath, fname))
+	if err != nil {
+		return err
+	}
+
+	entries := []manifestEntry{{fmt.Sprintf("%s.1", fname), size, sum, start, now}}
+
+	for _, e := range readManifest(R.name) {
+		n, ok := rotation(e.name, fname)
+		if !ok || n+1 > R.max_rotation {
+			continue
+		}
+		e.name = fmt.Sprintf("%s.%d", fname, n+1)
+		entries = append(entries, e)
+	}
+
+	return writeManifest(R.name, entries)
+}
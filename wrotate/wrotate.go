@@ -7,9 +7,11 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type rotaFile struct {
@@ -21,9 +23,14 @@ type rotaFile struct {
 	max_bytes    int64
 	bytes_left   int64
 	max_rotation uint
+	started      time.Time
 	write_lock   sync.Mutex
 }
 
+// ErrorHandler is called with errors that leave the log file writable, ie.. a failure to update its manifest, nil discards them.
+// It is called from the goroutine rotating the file, while writes are held in memory.
+var ErrorHandler func(name string, err error)
+
 const (
 	to_BUFFER = iota
 	to_FILE
@@ -38,7 +45,7 @@ func (f *rotaFile) Write(p []byte) (n int, err error) {
 
 	switch atomic.LoadUint32(&f.flag) {
 	case to_FILE:
-		if f.bytes_left < 0 {
+		if f.max_bytes > 0 && f.bytes_left < 0 {
 			// Rotate files in background while writing to memory.
 			atomic.StoreUint32(&f.flag, to_BUFFER)
 			go f.rotator()
@@ -59,6 +66,8 @@ func (f *rotaFile) Write(p []byte) (n int, err error) {
 
 // Creates a new log file (or opens an existing one) for writing.
 // max_bytes is threshold for rotation, max_rotation is number of previous logs to hold on to.
+// Each rotation updates a manifest (name.manifest) listing rotated files with their sizes, sha256 sums and time ranges.
+// The returned writer is never an *os.File, even with rotation disabled, so it can be reopened after external rotation.
 func OpenFile(name string, max_bytes int64, max_rotations uint) (io.WriteCloser, error) {
 	rotator := &rotaFile{
 		name:         name,
@@ -75,9 +84,10 @@ func OpenFile(name string, max_bytes int64, max_rotations uint) (io.WriteCloser,
 		return nil, err
 	}
 
-	// Just return the open file if max_bytes <= 0 or max_rotations <= 0.
+	// Disable rotation if max_bytes <= 0 or max_rotations <= 0.
 	if max_bytes <= 0 || max_rotations <= 0 {
-		return rotator.file, nil
+		rotator.max_bytes = 0
+		return rotator, nil
 	}
 
 	finfo, err := rotator.file.Stat()
@@ -86,10 +96,56 @@ func OpenFile(name string, max_bytes int64, max_rotations uint) (io.WriteCloser,
 	}
 
 	rotator.bytes_left = rotator.max_bytes - finfo.Size()
+	rotator.started = lastRotation(name)
 
 	return rotator, nil
 }
 
+// Returns name of log file.
+func (R *rotaFile) Name() string {
+	return R.name
+}
+
+// Reopens file when it was moved, removed or replaced by external rotation, ie.. logrotate.
+// A file truncated in place (copytruncate) is appended to from its new end.
+func (R *rotaFile) Reopen() (err error) {
+	R.write_lock.Lock()
+	defer R.write_lock.Unlock()
+
+	// Rotating, failed or closed.
+	if atomic.LoadUint32(&R.flag) != to_FILE {
+		return nil
+	}
+
+	current, err := R.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	finfo, err := os.Stat(R.name)
+	if err == nil && os.SameFile(current, finfo) {
+		R.bytes_left = R.max_bytes - finfo.Size()
+		return nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	file, err := os.OpenFile(R.name, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		return err
+	}
+	if finfo, err = file.Stat(); err != nil {
+		file.Close()
+		return err
+	}
+
+	R.file.Close()
+	R.file = file
+	R.bytes_left = R.max_bytes - finfo.Size()
+	return nil
+}
+
 // Closes logging file, removes file from all loggers, removes file from open files.
 func (R *rotaFile) Close() (err error) {
 	atomic.StoreUint32(&R.flag, _CLOSED)
@@ -126,7 +182,7 @@ func (R *rotaFile) rotator() {
 	files := make(map[string]os.FileInfo)
 
 	for _, v := range flist {
-		if strings.Contains(v.Name(), fname) {
+		if _, ok := rotation(v.Name(), fname); ok || v.Name() == fname {
 			files[v.Name()] = v
 		}
 	}
@@ -156,6 +212,11 @@ func (R *rotaFile) rotator() {
 		}
 	}
 
+	// Record rotated files in manifest, a failure is reported without failing the log.
+	if err = R.updateManifest(fpath, fname); err != nil && ErrorHandler != nil {
+		ErrorHandler(R.name, err)
+	}
+
 	// Open new file.
 	R.file, err = os.OpenFile(R.name, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0666)
 	if chkErr(err) {
@@ -180,3 +241,15 @@ func (R *rotaFile) rotator() {
 	atomic.StoreUint32(&R.flag, to_FILE)
 	return
 }
+
+// Returns rotation number of name, a rotated copy of log file fname, ie.. 2 for fname.2.
+func rotation(name, fname string) (n uint, ok bool) {
+	if !strings.HasPrefix(name, fname+".") {
+		return 0, false
+	}
+	i, err := strconv.ParseUint(strings.TrimPrefix(name, fname+"."), 10, 0)
+	if err != nil {
+		return 0, false
+	}
+	return uint(i), true
+}
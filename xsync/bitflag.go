@@ -1,6 +1,12 @@
 package xsync
 
-import "sync/atomic"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
 
 // Atomic BitFlag
 type BitFlag uint64
@@ -22,3 +28,68 @@ func (B *BitFlag) Set(flag uint64) bool {
 func (B *BitFlag) Unset(flag uint64) bool {
 	return atomic.CompareAndSwapUint64((*uint64)(B), atomic.LoadUint64((*uint64)(B))|uint64(flag), atomic.LoadUint64((*uint64)(B))&^uint64(flag))
 }
+
+var bit_names struct {
+	mutex sync.RWMutex
+	names map[uint64][]string
+}
+
+// Registers names of bits, used by String when rendering a BitFlag.
+// Names registered for the same bit by different callers are joined with '/'.
+func RegisterBits(names map[uint64]string) {
+	bit_names.mutex.Lock()
+	defer bit_names.mutex.Unlock()
+
+	if bit_names.names == nil {
+		bit_names.names = make(map[uint64][]string)
+	}
+
+	for bit, name := range names {
+		var found bool
+		for _, v := range bit_names.names[bit] {
+			if v == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			bit_names.names[bit] = append(bit_names.names[bit], name)
+		}
+	}
+}
+
+// Renders set bits by their registered names separated by '|', ie.. "trans_active|trans_closed".
+// Bits without a registered name are rendered in hex.
+func (B BitFlag) String() string {
+	value := uint64(B)
+	if value == 0 {
+		return "0"
+	}
+
+	bit_names.mutex.RLock()
+	defer bit_names.mutex.RUnlock()
+
+	var bits []uint64
+	for bit := range bit_names.names {
+		if bit != 0 && value&bit == bit {
+			bits = append(bits, bit)
+		}
+	}
+	sort.Slice(bits, func(i, j int) bool { return bits[i] < bits[j] })
+
+	var (
+		output []string
+		named  uint64
+	)
+
+	for _, bit := range bits {
+		output = append(output, strings.Join(bit_names.names[bit], "/"))
+		named |= bit
+	}
+
+	if rest := value &^ named; rest != 0 {
+		output = append(output, fmt.Sprintf("0x%x", rest))
+	}
+
+	return strings.Join(output, "|")
+}
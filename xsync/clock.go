@@ -0,0 +1,210 @@
+package xsync
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock provides the time to time-dependent components, so a FakeClock can be substituted in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) Ticker
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer calls a function once its time comes, like time.Timer created by time.AfterFunc.
+type Timer interface {
+	Stop() bool
+}
+
+// Ticker delivers ticks of a Clock, like time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// RealClock is the Clock of the time package.
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+type realTicker struct {
+	*time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.Ticker.C
+}
+
+// FakeClock is a Clock whose time only moves when advanced, firing timers and tickers which come due.
+type FakeClock struct {
+	mutex   sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*fakeTimer
+}
+
+// Timer or ticker of a FakeClock.
+type fakeTimer struct {
+	at     time.Time
+	period time.Duration // Period of a ticker, 0 for a timer.
+	ch     chan time.Time
+	fn     func() // Function called by a timer of AfterFunc, in place of sending on ch.
+	clock  *FakeClock
+}
+
+// Timer of a FakeClock created by AfterFunc.
+type fakeFunc struct {
+	t *fakeTimer
+}
+
+// Stops timer, returning false if its function was already called or the timer stopped.
+func (f fakeFunc) Stop() bool {
+	return f.t.remove()
+}
+
+// Returns a FakeClock set to start.
+func NewFakeClock(start time.Time) *FakeClock {
+	f := &FakeClock{now: start}
+	f.cond = sync.NewCond(&f.mutex)
+	return f
+}
+
+// Returns current time of the clock.
+func (f *FakeClock) Now() time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.now
+}
+
+// Returns channel receiving the time once the clock is advanced by d.
+func (f *FakeClock) After(d time.Duration) <-chan time.Time {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.add(&fakeTimer{at: f.now.Add(d), ch: ch, clock: f})
+	return ch
+}
+
+// Returns ticker firing each time the clock is advanced past another period of d, as with time.Ticker ticks are dropped for slow receivers.
+func (f *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	t := &fakeTimer{at: f.now.Add(d), period: d, ch: make(chan time.Time, 1), clock: f}
+	f.add(t)
+	return t
+}
+
+// Returns timer calling f in its own goroutine once the clock is advanced by d.
+func (f *FakeClock) AfterFunc(d time.Duration, fn func()) Timer {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	t := &fakeTimer{at: f.now.Add(d), fn: fn, clock: f}
+	if d <= 0 {
+		go fn()
+		return fakeFunc{t}
+	}
+	f.add(t)
+	return fakeFunc{t}
+}
+
+// Moves the clock forward by d, firing timers and tickers in order as they come due.
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	end := f.now.Add(d)
+
+	for len(f.waiters) > 0 && !f.waiters[0].at.After(end) {
+		t := f.waiters[0]
+		f.waiters = f.waiters[1:]
+		f.now = t.at
+
+		if t.fn != nil {
+			go t.fn()
+			continue
+		}
+
+		select {
+		case t.ch <- t.at:
+		default:
+		}
+
+		if t.period > 0 {
+			t.at = t.at.Add(t.period)
+			f.add(t)
+		}
+	}
+
+	f.now = end
+}
+
+// Blocks until at least n timers and tickers are waiting on the clock, so a test can advance it once a component is waiting.
+func (f *FakeClock) BlockUntil(n int) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+// Adds timer in order of when it is due, mutex must be held by caller.
+func (f *FakeClock) add(t *fakeTimer) {
+	i := sort.Search(len(f.waiters), func(i int) bool { return f.waiters[i].at.After(t.at) })
+	f.waiters = append(f.waiters, nil)
+	copy(f.waiters[i+1:], f.waiters[i:])
+	f.waiters[i] = t
+	f.cond.Broadcast()
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+// Stops ticker, no further ticks are delivered.
+func (t *fakeTimer) Stop() {
+	t.remove()
+}
+
+// Removes timer from the clock, returning false if it was no longer waiting.
+func (t *fakeTimer) remove() bool {
+	f := t.clock
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for i, w := range f.waiters {
+		if w == t {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
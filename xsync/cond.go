@@ -0,0 +1,72 @@
+package xsync
+
+import (
+	"context"
+	"sync"
+)
+
+// Cond is a condition variable, like sync.Cond, whose Wait returns when its context is done.
+type Cond struct {
+	L       sync.Locker
+	mutex   sync.Mutex
+	waiters []chan struct{}
+}
+
+// Returns a new Cond with Locker l.
+func NewCond(l sync.Locker) *Cond {
+	return &Cond{L: l}
+}
+
+// Wait unlocks c.L and waits for Signal or Broadcast, or for ctx to be done, then locks c.L before returning.
+// Returns ctx.Err() if ctx was done before being woken, as with sync.Cond the condition should be checked in a loop.
+func (c *Cond) Wait(ctx context.Context) error {
+	ch := make(chan struct{})
+
+	c.mutex.Lock()
+	c.waiters = append(c.waiters, ch)
+	c.mutex.Unlock()
+
+	c.L.Unlock()
+	defer c.L.Lock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i, w := range c.waiters {
+		if w == ch {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return ctx.Err()
+		}
+	}
+
+	// Woken while being cancelled, report as woken so the signal is not lost.
+	return nil
+}
+
+// Signal wakes one goroutine waiting on c, if there is any.
+func (c *Cond) Signal() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.waiters) > 0 {
+		close(c.waiters[0])
+		c.waiters = c.waiters[1:]
+	}
+}
+
+// Broadcast wakes all goroutines waiting on c.
+func (c *Cond) Broadcast() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, w := range c.waiters {
+		close(w)
+	}
+	c.waiters = nil
+}
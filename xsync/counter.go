@@ -0,0 +1,64 @@
+package xsync
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a counter sharded across processors, for frequent Add calls from many goroutines.
+// The zero value is ready to use, a Counter must not be copied after first use.
+type Counter struct {
+	once   sync.Once
+	shards []counterShard
+	pool   sync.Pool
+	next   uint32
+}
+
+// Shard padded to its own cache line.
+type counterShard struct {
+	value int64
+	_     [56]byte
+}
+
+// Creates shards, one for each processor.
+func (C *Counter) init() {
+	n := runtime.GOMAXPROCS(0)
+	C.shards = make([]counterShard, n)
+	// Shards are handed out through a sync.Pool, which keeps a goroutine on the same processor using the same shard.
+	C.pool.New = func() interface{} {
+		return &C.shards[int(atomic.AddUint32(&C.next, 1))%n]
+	}
+}
+
+// Adds delta to counter.
+func (C *Counter) Add(delta int64) {
+	C.once.Do(C.init)
+	s := C.pool.Get().(*counterShard)
+	atomic.AddInt64(&s.value, delta)
+	C.pool.Put(s)
+}
+
+// Adds one to counter.
+func (C *Counter) Inc() {
+	C.Add(1)
+}
+
+// Returns value of counter, including every Add which returned before Read was called.
+// Adds running concurrently with Read may or may not be included.
+func (C *Counter) Read() (total int64) {
+	C.once.Do(C.init)
+	for i := range C.shards {
+		total += atomic.LoadInt64(&C.shards[i].value)
+	}
+	return
+}
+
+// Returns value of counter and sets it to zero, no Add is lost between calls to Reset.
+func (C *Counter) Reset() (total int64) {
+	C.once.Do(C.init)
+	for i := range C.shards {
+		total += atomic.SwapInt64(&C.shards[i].value, 0)
+	}
+	return
+}
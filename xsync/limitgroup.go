@@ -3,11 +3,41 @@ LimitGroup is a sync.WaitGroup combined with a limiter, to limit how many thread
 */
 package xsync
 
-import "sync"
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 type limitGroup struct {
-	wg      sync.WaitGroup
-	limiter chan struct{}
+	wg       sync.WaitGroup
+	limiter  chan struct{}
+	mutex    sync.Mutex
+	deadline time.Duration
+	next_id  int
+	running  map[int]task
+	late     []Straggler
+	active   int64
+	finished uint64
+	ctx      context.Context
+	cancel   context.CancelFunc
+	err      error
+	clock    Clock
+}
+
+// Task admitted through AddTask.
+type task struct {
+	label string
+	start time.Time
+}
+
+// Straggler describes a task which ran past the deadline set with SetDeadline.
+type Straggler struct {
+	Label    string
+	Duration time.Duration // Time task took, or has been running for if still running.
+	Running  bool
 }
 
 type LimitGroup interface {
@@ -15,6 +45,15 @@ type LimitGroup interface {
 	Try() bool
 	Done()
 	Wait()
+	SetDeadline(d time.Duration)
+	SetClock(c Clock)
+	AddTask(label string) (done func())
+	Stragglers() []Straggler
+	WaitReport() []Straggler
+	WithCancel(ctx context.Context) context.Context
+	Go(fn func() error)
+	Err() error
+	WaitErr() error
 }
 
 func NewLimitGroup(max int) LimitGroup {
@@ -27,6 +66,7 @@ func NewLimitGroup(max int) LimitGroup {
 // If delta is larger than the limiter, Add panics.
 func (L *limitGroup) Add(n int) {
 	L.wg.Add(n)
+	atomic.AddInt64(&L.active, int64(n))
 	if L.limiter == nil {
 		return
 	}
@@ -46,10 +86,12 @@ func (L *limitGroup) Add(n int) {
 func (L *limitGroup) Try() bool {
 	L.wg.Add(1)
 	if L.limiter == nil {
+		atomic.AddInt64(&L.active, 1)
 		return true
 	}
 	select {
 	case L.limiter <- struct{}{}:
+		atomic.AddInt64(&L.active, 1)
 		return true
 	default:
 		L.wg.Done()
@@ -59,6 +101,8 @@ func (L *limitGroup) Try() bool {
 
 // Done decrements the LimitGroup counter by one.
 func (L *limitGroup) Done() {
+	atomic.AddInt64(&L.active, -1)
+	atomic.AddUint64(&L.finished, 1)
 	L.wg.Done()
 	if L.limiter != nil {
 		<-L.limiter
@@ -69,3 +113,147 @@ func (L *limitGroup) Done() {
 func (L *limitGroup) Wait() {
 	L.wg.Wait()
 }
+
+// Sets the time each task admitted through AddTask is expected to finish within, 0 disables tracking.
+func (L *limitGroup) SetDeadline(d time.Duration) {
+	L.mutex.Lock()
+	defer L.mutex.Unlock()
+	L.deadline = d
+}
+
+// Sets clock task durations are measured with, defaults to RealClock.
+func (L *limitGroup) SetClock(c Clock) {
+	L.mutex.Lock()
+	defer L.mutex.Unlock()
+	L.clock = c
+}
+
+// Returns current time of clock, mutex must be held by caller.
+func (L *limitGroup) now() time.Time {
+	if L.clock == nil {
+		return time.Now()
+	}
+	return L.clock.Now()
+}
+
+// AddTask adds a labeled task, blocking until one is available, returned function marks the task done.
+// Tasks exceeding the deadline set with SetDeadline are reported by Stragglers and WaitReport.
+func (L *limitGroup) AddTask(label string) (done func()) {
+	L.Add(1)
+
+	L.mutex.Lock()
+	defer L.mutex.Unlock()
+
+	if L.running == nil {
+		L.running = make(map[int]task)
+	}
+
+	id := L.next_id
+	L.next_id++
+	L.running[id] = task{label, L.now()}
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			L.mutex.Lock()
+			t := L.running[id]
+			delete(L.running, id)
+			if dur := L.now().Sub(t.start); L.deadline > 0 && dur > L.deadline {
+				L.late = append(L.late, Straggler{t.label, dur, false})
+			}
+			L.mutex.Unlock()
+			L.Done()
+		})
+	}
+}
+
+// Returns tasks which finished after their deadline, followed by running tasks already past it.
+func (L *limitGroup) Stragglers() (stragglers []Straggler) {
+	L.mutex.Lock()
+	defer L.mutex.Unlock()
+
+	stragglers = append(stragglers, L.late...)
+
+	if L.deadline <= 0 {
+		return
+	}
+
+	var running []Straggler
+	for _, t := range L.running {
+		if dur := L.now().Sub(t.start); dur > L.deadline {
+			running = append(running, Straggler{t.label, dur, true})
+		}
+	}
+	sort.Slice(running, func(i, j int) bool { return running[i].Duration > running[j].Duration })
+
+	return append(stragglers, running...)
+}
+
+// Reports whether tasks are running, and how many have finished, for Watchdog.
+func (L *limitGroup) progress() (busy bool, count uint64) {
+	return atomic.LoadInt64(&L.active) > 0, atomic.LoadUint64(&L.finished)
+}
+
+// WaitReport blocks until the LimitGroup is zero, then returns tasks which finished after their deadline.
+func (L *limitGroup) WaitReport() []Straggler {
+	L.wg.Wait()
+	return L.Stragglers()
+}
+
+// WithCancel returns a context derived from ctx, which is cancelled when a task started with Go returns an error, or by WaitErr.
+// Tasks started with Go after the context is done are skipped, so queued work short-circuits.
+func (L *limitGroup) WithCancel(ctx context.Context) context.Context {
+	L.mutex.Lock()
+	defer L.mutex.Unlock()
+	L.ctx, L.cancel = context.WithCancel(ctx)
+	return L.ctx
+}
+
+// Go runs fn in a new goroutine once a thread is available, the first error returned is kept for Err and WaitErr.
+func (L *limitGroup) Go(fn func() error) {
+	L.mutex.Lock()
+	ctx := L.ctx
+	L.mutex.Unlock()
+
+	if ctx != nil && ctx.Err() != nil {
+		return
+	}
+
+	L.Add(1)
+
+	go func() {
+		defer L.Done()
+		if ctx != nil && ctx.Err() != nil {
+			return
+		}
+		if err := fn(); err != nil {
+			L.mutex.Lock()
+			defer L.mutex.Unlock()
+			if L.err == nil {
+				L.err = err
+				if L.cancel != nil {
+					L.cancel()
+				}
+			}
+		}
+	}()
+}
+
+// Returns the first error returned by a task started with Go.
+func (L *limitGroup) Err() error {
+	L.mutex.Lock()
+	defer L.mutex.Unlock()
+	return L.err
+}
+
+// WaitErr blocks until the LimitGroup is zero, cancels the context of WithCancel, then returns the first error of Go.
+func (L *limitGroup) WaitErr() error {
+	L.wg.Wait()
+	L.mutex.Lock()
+	defer L.mutex.Unlock()
+	if L.cancel != nil {
+		L.cancel()
+	}
+	return L.err
+}
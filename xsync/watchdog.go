@@ -0,0 +1,136 @@
+package xsync
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Watcher periodically checks registered groups for progress, logging all goroutine stacks when one stalls.
+type Watcher struct {
+	interval time.Duration
+	mutex    sync.Mutex
+	watched  map[string]*watchedItem
+	logger   func(format string, args ...interface{})
+	stop     chan struct{}
+	once     sync.Once
+	clock    Clock
+}
+
+type watchedItem struct {
+	progress func() (busy bool, count uint64)
+	count    uint64
+	stalled  time.Duration
+	reported bool
+}
+
+// Watchdog starts a Watcher which checks registered groups every interval.
+// A group which is busy without progressing for an entire interval is reported once until it progresses again.
+func Watchdog(interval time.Duration) *Watcher {
+	return WatchdogWithClock(interval, RealClock)
+}
+
+// WatchdogWithClock starts a Watcher whose checks are timed by clock, ie.. a FakeClock in tests.
+func WatchdogWithClock(interval time.Duration, clock Clock) *Watcher {
+	W := &Watcher{
+		interval: interval,
+		clock:    clock,
+		watched:  make(map[string]*watchedItem),
+		stop:     make(chan struct{}),
+		logger: func(format string, args ...interface{}) {
+			fmt.Fprintf(os.Stderr, format+"\n", args...)
+		},
+	}
+	go W.run()
+	return W
+}
+
+// Sets function stalls are logged through, ie.. nfo.Warn, defaults to writing to stderr.
+func (W *Watcher) SetLogger(fn func(format string, args ...interface{})) {
+	W.mutex.Lock()
+	defer W.mutex.Unlock()
+	W.logger = fn
+}
+
+// Watches a progress function, which reports whether work is outstanding and a count which increases as work completes.
+func (W *Watcher) Watch(name string, progress func() (busy bool, count uint64)) {
+	W.mutex.Lock()
+	defer W.mutex.Unlock()
+	_, count := progress()
+	W.watched[name] = &watchedItem{progress: progress, count: count}
+}
+
+// Watches a LimitGroup, which progresses each time a task is done.
+func (W *Watcher) WatchGroup(name string, group LimitGroup) {
+	if lg, ok := group.(*limitGroup); ok {
+		W.Watch(name, lg.progress)
+	}
+}
+
+// Stops watching name.
+func (W *Watcher) Unwatch(name string) {
+	W.mutex.Lock()
+	defer W.mutex.Unlock()
+	delete(W.watched, name)
+}
+
+// Stops Watcher.
+func (W *Watcher) Stop() {
+	W.once.Do(func() { close(W.stop) })
+}
+
+// Checks watched items every interval.
+func (W *Watcher) run() {
+	ticker := W.clock.NewTicker(W.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-W.stop:
+			return
+		case <-ticker.C():
+			W.check()
+		}
+	}
+}
+
+// Logs stacks of all goroutines when a watched item has stalled.
+func (W *Watcher) check() {
+	W.mutex.Lock()
+	defer W.mutex.Unlock()
+
+	var stalled []string
+
+	for name, w := range W.watched {
+		busy, count := w.progress()
+		if !busy || count != w.count {
+			w.count = count
+			w.stalled = 0
+			w.reported = false
+			continue
+		}
+		w.stalled += W.interval
+		if !w.reported {
+			w.reported = true
+			stalled = append(stalled, fmt.Sprintf("%s (no progress in %s)", name, w.stalled))
+		}
+	}
+
+	for _, s := range stalled {
+		W.logger("watchdog: %s, goroutine stacks:\n%s", s, stacks())
+	}
+}
+
+// Returns stacks of all goroutines.
+func stacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}